@@ -1,18 +1,41 @@
 package api
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
 
 	"github.com/zalando/go-keyring"
 )
 
 const (
-	BaseURL     = "http://ytrss.xyz/api/v1"
+	BaseURL     = "https://ytrss.xyz/api/v1"
 	serviceName = "ytrss-cli"
 )
 
-var apiClient = NewAPIClient(BaseURL)
+const (
+	StatusCreated = "CREATED"
+	StatusSuccess = "SUCCESS"
+	StatusError   = "ERROR"
+)
+
+const DefaultRequestTimeout = 30 * time.Second
+
+// Service is the set of API operations, implemented by Client. Consumers
+// such as the TUI depend on it so they can be tested with fakes.
+type Service interface {
+	ListPodcasts(ctx context.Context) ([]Podcast, error)
+	AddUrlToPodcast(ctx context.Context, podcastID, url string) (Item, error)
+	GetPodcastItems(ctx context.Context, podcastID string) ([]Item, error)
+	GetUsage(ctx context.Context) (*UsageResponse, error)
+	VerifyAPIKey(ctx context.Context, key string) (*UsageResponse, error)
+}
+
+var _ Service = (*Client)(nil)
+
+var defaultClient, _ = NewClient()
 
 type Podcast struct {
 	ID    string `json:"id"`
@@ -36,6 +59,27 @@ type UsageResponse struct {
 	Limit int `json:"limit"`
 }
 
+func (u UsageResponse) String() string {
+	return FormatBytes(u.Usage) + " / " + FormatBytes(u.Limit)
+}
+
+func FormatBytes(bytes int) string {
+	const (
+		KB = 1024
+		MB = 1024 * KB
+		GB = 1024 * MB
+	)
+
+	if bytes >= GB {
+		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
+	} else if bytes >= MB {
+		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
+	} else if bytes >= KB {
+		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+	}
+	return fmt.Sprintf("%d B", bytes)
+}
+
 type Item struct {
 	Status  string `json:"status"`
 	Title   string `json:"title,omitempty"`
@@ -43,61 +87,77 @@ type Item struct {
 	Created string `json:"created,omitempty"`
 }
 
-func GetApiKey() (string, error) {
-	return keyring.Get(serviceName, "api_key")
-}
+// DefaultProfile is the profile used when none is selected. Its key is
+// stored under the keyring user "api_key" so keys saved before profiles
+// existed keep working.
+const DefaultProfile = "default"
 
-func SetApiKey(apiKey string) error {
-	return keyring.Set(serviceName, "api_key", apiKey)
+func keyringUser(profile string) string {
+	if profile == "" || profile == DefaultProfile {
+		return "api_key"
+	}
+	return "api_key:" + profile
 }
 
-func ClearApiKey() error {
-	return keyring.Delete(serviceName, "api_key")
+func GetApiKey(profile string) (string, error) {
+	return keyring.Get(serviceName, keyringUser(profile))
 }
 
-func ListPodcasts() ([]Podcast, error) {
-	var podcasts []Podcast
-	err := apiClient.do("GET", "/list-podcasts", nil, &podcasts)
-	if err != nil {
-		return nil, err
+// SetApiKey stores the API key of profile in the OS keyring. When the
+// keyring is unavailable and $YTRSS_CREDENTIALS_PASSPHRASE is set, the key
+// is written to the profile's encrypted credentials file instead.
+func SetApiKey(profile, apiKey string) error {
+	err := keyring.Set(serviceName, keyringUser(profile), apiKey)
+	if err != nil && os.Getenv(PassphraseEnv) != "" {
+		return EncryptedFileCredentials{Profile: profile}.Save(apiKey)
 	}
-	return podcasts, nil
+	return err
 }
 
-func AddUrlToPodcast(podcastID, url string) (Item, error) {
-	requestBody := AddUrlRequestBody{
-		PodcastID: podcastID,
-		URL:       url,
+// ClearApiKey removes the API key of profile from the OS keyring and the
+// encrypted credentials file.
+func ClearApiKey(profile string) error {
+	err := keyring.Delete(serviceName, keyringUser(profile))
+	if errors.Is(err, keyring.ErrNotFound) {
+		err = nil
 	}
+	return errors.Join(err, EncryptedFileCredentials{Profile: profile}.Delete())
+}
 
-	jsonBody, err := json.Marshal(requestBody)
-	if err != nil {
-		return Item{}, err
-	}
+// Default returns the client used by the package-level functions.
+func Default() *Client {
+	return defaultClient
+}
 
-	var item Item
-	err = apiClient.do("POST", "/podcasts/add-url", bytes.NewBuffer(jsonBody), &item)
-	if err != nil {
-		return Item{}, err
-	}
+// SetDefault replaces the client used by the package-level functions.
+func SetDefault(c *Client) {
+	defaultClient = c
+}
 
-	return item, nil
+func ListPodcasts(ctx context.Context) ([]Podcast, error) {
+	return defaultClient.ListPodcasts(ctx)
 }
 
-func GetPodcastItems(podcastID string) ([]Item, error) {
-	var items []Item
-	err := apiClient.do("GET", "/get-items/"+podcastID, nil, &items)
-	if err != nil {
-		return nil, err
-	}
-	return items, nil
+func AddUrlToPodcast(ctx context.Context, podcastID, url string) (Item, error) {
+	return defaultClient.AddUrlToPodcast(ctx, podcastID, url)
 }
 
-func GetUsage() (*UsageResponse, error) {
-	var usageResponse UsageResponse
-	err := apiClient.do("GET", "/get-usage", nil, &usageResponse)
-	if err != nil {
-		return nil, err
-	}
-	return &usageResponse, nil
+func GetPodcastItems(ctx context.Context, podcastID string) ([]Item, error) {
+	return defaultClient.GetPodcastItems(ctx, podcastID)
+}
+
+func GetUsage(ctx context.Context) (*UsageResponse, error) {
+	return defaultClient.GetUsage(ctx)
+}
+
+func VerifyAPIKey(ctx context.Context, key string) (*UsageResponse, error) {
+	return defaultClient.VerifyAPIKey(ctx, key)
+}
+
+func WaitForItems(ctx context.Context, podcastID string, interval time.Duration, done func([]Item) bool) ([]Item, error) {
+	return defaultClient.WaitForItems(ctx, podcastID, interval, done)
+}
+
+func WaitForItem(ctx context.Context, podcastID string, submitted Item, interval time.Duration) (Item, error) {
+	return defaultClient.WaitForItem(ctx, podcastID, submitted, interval)
 }
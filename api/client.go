@@ -1,59 +1,379 @@
 package api
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 )
 
-type APIClient struct {
-	client  *http.Client
-	baseURL string
+const DefaultUserAgent = "ytrss-cli"
+
+// Client talks to the ytrss API. Create one with NewClient; the zero value
+// is not usable.
+type Client struct {
+	client            *http.Client
+	baseURL           string
+	allowInsecureHTTP bool
+	credentials       CredentialProvider
+	key               *keyCache
+	userAgent         string
+	timeout           time.Duration
+	retry             RetryPolicy
+	logger            *slog.Logger
+}
+
+type Option func(*Client)
+
+// keyCache holds the API key once resolved from the credentials, so that
+// slow providers such as a command or the encrypted file run once per
+// client rather than for every request.
+type keyCache struct {
+	mu     sync.Mutex
+	key    string
+	source CredentialProvider
+}
+
+// WithBaseURL points the client at another server, e.g. staging or a local
+// stand-in. Only http and https URLs are accepted.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) { c.baseURL = baseURL }
+}
+
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) { c.client = client }
 }
 
-func NewAPIClient(baseURL string) *APIClient {
-	return &APIClient{
-		client:  &http.Client{},
-		baseURL: baseURL,
+func WithCredentials(credentials CredentialProvider) Option {
+	return func(c *Client) { c.credentials = credentials }
+}
+
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.userAgent = userAgent }
+}
+
+// WithLogger sets where the client reports requests and retries, at debug
+// level.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) { c.logger = logger }
+}
+
+// WithRequestTimeout limits how long a single attempt may take. Zero
+// disables the limit, leaving only the caller's context.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(c *Client) { c.timeout = timeout }
+}
+
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) { c.retry = policy }
+}
+
+// WithInsecureHTTP allows sending the API key over plain HTTP to hosts
+// other than loopback addresses.
+func WithInsecureHTTP(allow bool) Option {
+	return func(c *Client) { c.allowInsecureHTTP = allow }
+}
+
+// NewClient returns a client for the ytrss API. By default it talks to
+// BaseURL with the API key from DefaultCredentials.
+func NewClient(opts ...Option) (*Client, error) {
+	c := &Client{
+		client:      &http.Client{},
+		baseURL:     BaseURL,
+		credentials: DefaultCredentials(DefaultProfile, "", ""),
+		key:         &keyCache{},
+		userAgent:   DefaultUserAgent,
+		timeout:     DefaultRequestTimeout,
+		retry:       DefaultRetryPolicy,
+		logger:      slog.New(slog.DiscardHandler),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid API URL %q: %w", c.baseURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid API URL %q: scheme must be http or https", c.baseURL)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid API URL %q: missing host", c.baseURL)
+	}
+	c.baseURL = strings.TrimSuffix(u.String(), "/")
+
+	if c.client == nil {
+		c.client = &http.Client{}
+	}
+	if c.logger == nil {
+		c.logger = slog.New(slog.DiscardHandler)
 	}
+	return c, nil
 }
 
-func (c *APIClient) do(method, path string, body io.Reader, v any) error {
-	apiKey, err := GetApiKey()
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+func (c *Client) Credentials() CredentialProvider {
+	return c.credentials
+}
+
+// ResolveAPIKey returns the API key the client sends and the provider that
+// supplied it. The credentials are only read on first use; the key is then
+// cached until the server rejects it or ForgetAPIKey is called. Failures
+// are not cached.
+func (c *Client) ResolveAPIKey(ctx context.Context) (string, CredentialProvider, error) {
+	c.key.mu.Lock()
+	defer c.key.mu.Unlock()
+	if c.key.key != "" {
+		return c.key.key, c.key.source, nil
+	}
+
+	var key string
+	source := c.credentials
+	var err error
+	if chain, ok := c.credentials.(CredentialChain); ok {
+		key, source, err = chain.Resolve(ctx)
+	} else {
+		key, err = c.credentials.APIKey(ctx)
+	}
+	if err != nil {
+		return "", source, err
+	}
+	if key == "" {
+		return "", source, ErrNoAPIKey
+	}
+	c.key.key, c.key.source = key, source
+	return key, source, nil
+}
+
+// ForgetAPIKey makes the next request read the credentials again, e.g.
+// after the stored key changed.
+func (c *Client) ForgetAPIKey() {
+	c.forgetAPIKey("")
+}
+
+// forgetAPIKey drops the cached key, only if it is still key when key is
+// not empty, so that a key resolved concurrently is kept.
+func (c *Client) forgetAPIKey(key string) {
+	c.key.mu.Lock()
+	defer c.key.mu.Unlock()
+	if key == "" || c.key.key == key {
+		c.key.key, c.key.source = "", nil
+	}
+}
+
+func (c *Client) ListPodcasts(ctx context.Context) ([]Podcast, error) {
+	var podcasts []Podcast
+	err := c.do(ctx, "GET", "/list-podcasts", nil, &podcasts, "")
+	if err != nil {
+		return nil, err
+	}
+	return podcasts, nil
+}
+
+func (c *Client) AddUrlToPodcast(ctx context.Context, podcastID, url string) (Item, error) {
+	requestBody := AddUrlRequestBody{
+		PodcastID: podcastID,
+		URL:       url,
+	}
+
+	jsonBody, err := json.Marshal(requestBody)
 	if err != nil {
-		return fmt.Errorf("API key not set. Please set an API key")
+		return Item{}, err
 	}
 
-	req, err := http.NewRequest(method, c.baseURL+path, body)
+	var item Item
+	err = c.do(ctx, "POST", "/podcasts/add-url", jsonBody, &item, newIdempotencyKey())
+	if err != nil {
+		return Item{}, err
+	}
+
+	return item, nil
+}
+
+func (c *Client) GetPodcastItems(ctx context.Context, podcastID string) ([]Item, error) {
+	var items []Item
+	err := c.do(ctx, "GET", "/get-items/"+podcastID, nil, &items, "")
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (c *Client) GetUsage(ctx context.Context) (*UsageResponse, error) {
+	var usageResponse UsageResponse
+	err := c.do(ctx, "GET", "/get-usage", nil, &usageResponse, "")
+	if err != nil {
+		return nil, err
+	}
+	return &usageResponse, nil
+}
+
+// VerifyAPIKey checks key with an authenticated request, without storing
+// it, and returns the account's usage. A rejected key yields an error
+// matching ErrUnauthorized.
+func (c *Client) VerifyAPIKey(ctx context.Context, key string) (*UsageResponse, error) {
+	probe := *c
+	probe.credentials = StaticAPIKey(key)
+	probe.key = &keyCache{}
+	return probe.GetUsage(ctx)
+}
+
+type response struct {
+	status     string
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+// do sends a request and decodes the JSON response into v. GET requests
+// and requests carrying an idempotency key are retried according to the
+// client's retry policy.
+func (c *Client) do(ctx context.Context, method, path string, body []byte, v any, idempotencyKey string) error {
+	if err := c.checkTransport(); err != nil {
+		return err
+	}
+
+	apiKey, _, err := c.ResolveAPIKey(ctx)
+	if errors.Is(err, ErrNoAPIKey) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("reading API key: %w", err)
+	}
+
+	maxAttempts := 1
+	if method == http.MethodGet || idempotencyKey != "" {
+		maxAttempts = max(c.retry.MaxAttempts, 1)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.send(ctx, method, path, body, apiKey, idempotencyKey)
+		if err == nil && resp.statusCode >= 200 && resp.statusCode < 300 {
+			c.logger.Debug("API request succeeded", "method", method, "path", path, "status", resp.statusCode, "attempts", attempt)
+			return decode(resp, v)
+		}
+		if err == nil {
+			err = newAPIError(resp)
+		}
+		if errors.Is(err, ErrUnauthorized) {
+			c.forgetAPIKey(apiKey)
+		}
+
+		if attempt >= maxAttempts || ctx.Err() != nil || !c.retry.shouldRetry(resp) {
+			c.logger.Debug("API request failed", "method", method, "path", path, "attempts", attempt, "error", err)
+			return err
+		}
+
+		delay := c.retry.delay(attempt, resp)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			c.logger.Debug("not retrying API request past the deadline", "method", method, "path", path, "attempts", attempt, "delay", delay)
+			return err
+		}
+
+		c.logger.Debug("retrying API request", "method", method, "path", path, "attempt", attempt, "max_attempts", maxAttempts, "delay", delay, "error", err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// checkTransport refuses to send the API key in clear text, except to
+// loopback hosts or when explicitly allowed.
+func (c *Client) checkTransport() error {
+	u, err := url.Parse(c.baseURL)
 	if err != nil {
 		return err
 	}
+	if u.Scheme != "http" || c.allowInsecureHTTP || isLoopback(u.Hostname()) {
+		return nil
+	}
+	return fmt.Errorf("refusing to send the API key over plain HTTP to %s: use https or allow insecure HTTP explicitly", u.Host)
+}
+
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// send performs a single attempt and reads the whole response body. A nil
+// response is returned when the server could not be reached.
+func (c *Client) send(ctx context.Context, method, path string, body []byte, apiKey, idempotencyKey string) (*response, error) {
+	reqCtx := ctx
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(reqCtx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
 
 	req.Header.Set("Authorization", "Bearer "+apiKey)
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("could not connect to the API")
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &NetworkError{Err: err}
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API request failed: %s - %s", resp.Status, string(bodyBytes))
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &NetworkError{Err: fmt.Errorf("failed to read response body: %w", err)}
 	}
 
-	if v != nil {
-		bodyBytes, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return fmt.Errorf("failed to read response body: %w", err)
-		}
+	return &response{
+		status:     resp.Status,
+		statusCode: resp.StatusCode,
+		header:     resp.Header,
+		body:       bodyBytes,
+	}, nil
+}
 
-		if err := json.Unmarshal(bodyBytes, v); err != nil {
-			return fmt.Errorf("failed to decode JSON response (status %d): %w\nResponse body: %s", resp.StatusCode, err, string(bodyBytes))
-		}
+func decode(resp *response, v any) error {
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(resp.body, v); err != nil {
+		return fmt.Errorf("failed to decode JSON response (status %d): %w\nResponse body: %s", resp.statusCode, err, string(resp.body))
 	}
-
 	return nil
 }
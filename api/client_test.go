@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCredentialsResolvedOnce(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	var reads atomic.Int32
+	creds := CredentialFunc(func(context.Context) (string, error) {
+		reads.Add(1)
+		return "test-key", nil
+	})
+	c := newTestClient(t, srv.URL, fastRetries, WithCredentials(creds))
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.ListPodcasts(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := reads.Load(); got != 1 {
+		t.Errorf("credentials read %d times, want 1", got)
+	}
+
+	c.ForgetAPIKey()
+	c.ListPodcasts(context.Background())
+	if got := reads.Load(); got != 2 {
+		t.Errorf("credentials read %d times after ForgetAPIKey, want 2", got)
+	}
+}
+
+func TestRejectedKeyIsResolvedAgain(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer new-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[]`))
+	}))
+	defer srv.Close()
+
+	key := "old-key"
+	c := newTestClient(t, srv.URL, fastRetries, WithCredentials(CredentialFunc(func(context.Context) (string, error) {
+		return key, nil
+	})))
+
+	if _, err := c.ListPodcasts(context.Background()); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("error = %v, want ErrUnauthorized", err)
+	}
+	key = "new-key"
+	if _, err := c.ListPodcasts(context.Background()); err != nil {
+		t.Errorf("the key was not read again after it was rejected: %v", err)
+	}
+}
+
+func TestResolveAPIKeySource(t *testing.T) {
+	t.Setenv(APIKeyEnv, "")
+	chain := CredentialChain{EnvCredentials{Var: APIKeyEnv}, StaticAPIKey("from-static")}
+	c, err := NewClient(WithCredentials(chain))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, source, err := c.ResolveAPIKey(context.Background())
+	if err != nil || key != "from-static" {
+		t.Fatalf("ResolveAPIKey = %q, %v", key, err)
+	}
+	if _, ok := source.(CredentialFunc); !ok {
+		t.Errorf("source = %T, want the provider that supplied the key", source)
+	}
+
+	c, _ = NewClient(WithCredentials(CredentialChain{EnvCredentials{Var: APIKeyEnv}}))
+	if _, _, err := c.ResolveAPIKey(context.Background()); !errors.Is(err, ErrNoAPIKey) {
+		t.Errorf("error = %v, want ErrNoAPIKey", err)
+	}
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/zalando/go-keyring"
+)
+
+const APIKeyEnv = "YTRSS_API_KEY"
+
+// CredentialProvider supplies the API key, which a Client reads once and
+// caches. Providers without a key return an error matching ErrNoAPIKey.
+type CredentialProvider interface {
+	APIKey(ctx context.Context) (string, error)
+}
+
+// CredentialFunc adapts a function to CredentialProvider.
+type CredentialFunc func(ctx context.Context) (string, error)
+
+func (f CredentialFunc) APIKey(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// StaticAPIKey always returns key.
+func StaticAPIKey(key string) CredentialProvider {
+	return CredentialFunc(func(context.Context) (string, error) {
+		return key, nil
+	})
+}
+
+// EnvCredentials reads the API key from an environment variable.
+type EnvCredentials struct {
+	Var string
+}
+
+func (c EnvCredentials) APIKey(context.Context) (string, error) {
+	if key := strings.TrimSpace(os.Getenv(c.Var)); key != "" {
+		return key, nil
+	}
+	return "", ErrNoAPIKey
+}
+
+func (c EnvCredentials) String() string {
+	return "environment variable " + c.Var
+}
+
+// FileCredentials reads the API key from a plain text file. An empty Path
+// means no file is configured.
+type FileCredentials struct {
+	Path string
+}
+
+func (c FileCredentials) APIKey(context.Context) (string, error) {
+	if c.Path == "" {
+		return "", ErrNoAPIKey
+	}
+	data, err := os.ReadFile(c.Path)
+	if err != nil {
+		return "", fmt.Errorf("reading API key file: %w", err)
+	}
+	key := strings.TrimSpace(string(data))
+	if key == "" {
+		return "", fmt.Errorf("API key file %s is empty", c.Path)
+	}
+	return key, nil
+}
+
+func (c FileCredentials) String() string {
+	return "file " + c.Path
+}
+
+// CommandCredentials runs a shell command, such as a password manager
+// helper, and uses its output as the API key. An empty Command means no
+// helper is configured.
+type CommandCredentials struct {
+	Command string
+}
+
+func (c CommandCredentials) APIKey(ctx context.Context) (string, error) {
+	if c.Command == "" {
+		return "", ErrNoAPIKey
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.Command)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("API key command failed: %w: %s", err, msg)
+		}
+		return "", fmt.Errorf("API key command failed: %w", err)
+	}
+
+	key := strings.TrimSpace(stdout.String())
+	if key == "" {
+		return "", fmt.Errorf("API key command printed nothing")
+	}
+	return key, nil
+}
+
+func (c CommandCredentials) String() string {
+	return "command `" + c.Command + "`"
+}
+
+// KeyringCredentials reads the API key of a profile stored by SetApiKey in
+// the OS keyring. An unavailable keyring is treated as having no key.
+type KeyringCredentials struct {
+	Profile string
+}
+
+func (c KeyringCredentials) APIKey(context.Context) (string, error) {
+	key, err := GetApiKey(c.Profile)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", ErrNoAPIKey
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w (keyring unavailable: %v)", ErrNoAPIKey, err)
+	}
+	return key, nil
+}
+
+func (c KeyringCredentials) String() string {
+	return "OS keyring (" + keyringUser(c.Profile) + ")"
+}
+
+// CredentialChain tries each provider in order and uses the first key
+// found. Providers that have no key are skipped; any other error stops the
+// chain.
+type CredentialChain []CredentialProvider
+
+func (c CredentialChain) APIKey(ctx context.Context) (string, error) {
+	key, _, err := c.Resolve(ctx)
+	return key, err
+}
+
+// Resolve returns the first key found along with the provider that
+// supplied it.
+func (c CredentialChain) Resolve(ctx context.Context) (string, CredentialProvider, error) {
+	for _, p := range c {
+		key, err := p.APIKey(ctx)
+		if errors.Is(err, ErrNoAPIKey) {
+			continue
+		}
+		if err != nil {
+			return "", p, err
+		}
+		return key, p, nil
+	}
+	return "", nil, ErrNoAPIKey
+}
+
+// DefaultCredentials returns the standard lookup order for profile:
+// $YTRSS_API_KEY, keyFile, command, the encrypted credentials file and the
+// OS keyring. keyFile and command may be empty.
+func DefaultCredentials(profile, keyFile, command string) CredentialChain {
+	return CredentialChain{
+		EnvCredentials{Var: APIKeyEnv},
+		FileCredentials{Path: keyFile},
+		CommandCredentials{Command: command},
+		EncryptedFileCredentials{Profile: profile},
+		KeyringCredentials{Profile: profile},
+	}
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/lsherman98/ytrss-cli/xdg"
+)
+
+const (
+	PassphraseEnv = "YTRSS_CREDENTIALS_PASSPHRASE"
+
+	encryptedFileName  = "credentials"
+	pbkdf2Iterations   = 600_000
+	encryptedKeyLength = 32
+)
+
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// EncryptedFileCredentials reads the API key from a file encrypted with a
+// passphrase, for machines without a usable OS keyring. Path defaults to
+// credentials.enc in the config directory, or credentials-<profile>.enc for
+// profiles other than the default, and Passphrase to
+// $YTRSS_CREDENTIALS_PASSPHRASE. Without a file or passphrase it has no
+// key.
+type EncryptedFileCredentials struct {
+	Path       string
+	Passphrase string
+	Profile    string
+}
+
+func (c EncryptedFileCredentials) APIKey(context.Context) (string, error) {
+	path, err := c.path()
+	if err != nil {
+		return "", err
+	}
+	passphrase := c.passphrase()
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", ErrNoAPIKey
+	}
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("%w (%s exists but %s is not set)", ErrNoAPIKey, path, PassphraseEnv)
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return "", fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	gcm, err := newGCM(passphrase, file.Salt)
+	if err != nil {
+		return "", err
+	}
+	key, err := gcm.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypting %s: wrong passphrase or corrupted file", path)
+	}
+	return string(key), nil
+}
+
+// Save encrypts key and writes it to the credentials file.
+func (c EncryptedFileCredentials) Save(key string) error {
+	path, err := c.path()
+	if err != nil {
+		return err
+	}
+	passphrase := c.passphrase()
+	if passphrase == "" {
+		return fmt.Errorf("set %s to store the API key in an encrypted file", PassphraseEnv)
+	}
+
+	file := encryptedFile{Version: 1, Salt: make([]byte, 16)}
+	rand.Read(file.Salt)
+
+	gcm, err := newGCM(passphrase, file.Salt)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	rand.Read(file.Nonce)
+	file.Ciphertext = gcm.Seal(nil, file.Nonce, []byte(key), nil)
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// Delete removes the credentials file if it exists.
+func (c EncryptedFileCredentials) Delete() error {
+	path, err := c.path()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (c EncryptedFileCredentials) String() string {
+	path, _ := c.path()
+	return "encrypted file " + path
+}
+
+func (c EncryptedFileCredentials) path() (string, error) {
+	if c.Path != "" {
+		return c.Path, nil
+	}
+	dir, err := xdg.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	if c.Profile == "" || c.Profile == DefaultProfile {
+		return filepath.Join(dir, encryptedFileName+".enc"), nil
+	}
+	return filepath.Join(dir, encryptedFileName+"-"+c.Profile+".enc"), nil
+}
+
+func (c EncryptedFileCredentials) passphrase() string {
+	if c.Passphrase != "" {
+		return c.Passphrase
+	}
+	return os.Getenv(PassphraseEnv)
+}
+
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, encryptedKeyLength)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrNoAPIKey      = errors.New("API key not set. Please set an API key")
+	ErrUnauthorized  = errors.New("the API key was rejected")
+	ErrQuotaExceeded = errors.New("usage quota exceeded")
+	ErrNotFound      = errors.New("not found")
+)
+
+// APIError is a non-2xx response from the API. It matches ErrUnauthorized,
+// ErrQuotaExceeded and ErrNotFound with errors.Is.
+type APIError struct {
+	StatusCode int
+	Status     string
+	Code       string
+	Message    string
+	RequestID  string
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "API request failed: %s", e.Status)
+	if e.Message != "" {
+		fmt.Fprintf(&b, " - %s", e.Message)
+	}
+	if e.Code != "" {
+		fmt.Fprintf(&b, " (%s)", e.Code)
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " [request %s]", e.RequestID)
+	}
+	return b.String()
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrQuotaExceeded:
+		return e.isQuota()
+	}
+	return false
+}
+
+func (e *APIError) isQuota() bool {
+	if e.StatusCode == http.StatusPaymentRequired {
+		return true
+	}
+	if e.StatusCode != http.StatusTooManyRequests && e.StatusCode != http.StatusForbidden {
+		return false
+	}
+	text := strings.ToLower(e.Code + " " + e.Message)
+	return strings.Contains(text, "quota") || strings.Contains(text, "limit exceeded") || strings.Contains(text, "usage limit")
+}
+
+// NetworkError wraps failures to reach the API, including per-request
+// timeouts.
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	if e.Timeout() {
+		return "API request timed out: " + e.Err.Error()
+	}
+	return "could not connect to the API: " + e.Err.Error()
+}
+
+func (e *NetworkError) Unwrap() error { return e.Err }
+
+func (e *NetworkError) Timeout() bool {
+	if errors.Is(e.Err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(e.Err, &netErr) && netErr.Timeout()
+}
+
+// newAPIError builds an APIError from a response, reading the message and
+// code from JSON bodies such as {"code": "...", "message": "..."} and
+// falling back to the raw body.
+func newAPIError(resp *response) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.statusCode,
+		Status:     resp.status,
+		RequestID:  resp.header.Get("X-Request-Id"),
+	}
+
+	var body struct {
+		Code      any    `json:"code"`
+		Message   string `json:"message"`
+		Error     string `json:"error"`
+		RequestID string `json:"request_id"`
+	}
+	if err := json.Unmarshal(resp.body, &body); err == nil {
+		if body.Code != nil {
+			apiErr.Code = fmt.Sprint(body.Code)
+		}
+		apiErr.Message = body.Message
+		if apiErr.Message == "" {
+			apiErr.Message = body.Error
+		}
+		if apiErr.RequestID == "" {
+			apiErr.RequestID = body.RequestID
+		}
+	} else {
+		apiErr.Message = strings.TrimSpace(string(resp.body))
+	}
+
+	return apiErr
+}
+
+// Temporary reports whether the request that failed with err is worth
+// sending again later: the API could not be reached or was unavailable.
+// Running out of quota is not temporary.
+func Temporary(err error) bool {
+	var netErr *NetworkError
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return !apiErr.isQuota() && (apiErr.StatusCode >= 500 || apiErr.StatusCode == http.StatusTooManyRequests)
+	}
+	return false
+}
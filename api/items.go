@@ -0,0 +1,51 @@
+package api
+
+import (
+	"sort"
+	"time"
+)
+
+func ParseCreatedTime(created string) time.Time {
+	if created == "" {
+		return time.Time{}
+	}
+
+	layouts := []string{
+		time.RFC3339Nano,
+		time.RFC3339,
+		"2006-01-02 15:04:05.999Z",
+		"2006-01-02 15:04:05Z",
+		"2006-01-02 15:04:05.999Z07:00",
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, created); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// SortItemsByCreated returns a copy of items ordered newest first, with
+// items whose creation time cannot be parsed at the end.
+func SortItemsByCreated(items []Item) []Item {
+	sortedItems := make([]Item, len(items))
+	copy(sortedItems, items)
+	sort.SliceStable(sortedItems, func(i, j int) bool {
+		timeI := ParseCreatedTime(sortedItems[i].Created)
+		timeJ := ParseCreatedTime(sortedItems[j].Created)
+
+		if timeI.IsZero() && timeJ.IsZero() {
+			return false
+		}
+		if timeI.IsZero() {
+			return false
+		}
+		if timeJ.IsZero() {
+			return true
+		}
+
+		return timeI.After(timeJ)
+	})
+	return sortedItems
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	mathrand "math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried. Delays grow
+// exponentially from BaseDelay up to MaxDelay with random jitter, unless the
+// server asks for a specific delay with Retry-After.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// RetryStatuses are the HTTP status codes worth retrying. Requests that
+	// could not reach the server are always retried.
+	RetryStatuses []int
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:   4,
+	BaseDelay:     500 * time.Millisecond,
+	MaxDelay:      15 * time.Second,
+	RetryStatuses: []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+}
+
+// shouldRetry reports whether an attempt that ended with resp is worth
+// repeating. resp is nil when the server could not be reached.
+func (p RetryPolicy) shouldRetry(resp *response) bool {
+	if resp == nil {
+		return true
+	}
+	return slices.Contains(p.RetryStatuses, resp.statusCode)
+}
+
+// delay returns how long to wait after the given 1-based attempt.
+func (p RetryPolicy) delay(attempt int, resp *response) time.Duration {
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.header.Get("Retry-After"), time.Now()); ok {
+			return d
+		}
+	}
+
+	backoff := p.BaseDelay << (attempt - 1)
+	if backoff <= 0 || (p.MaxDelay > 0 && backoff > p.MaxDelay) {
+		backoff = p.MaxDelay
+	}
+	if backoff <= 0 {
+		return 0
+	}
+
+	half := backoff / 2
+	return half + time.Duration(mathrand.Int64N(int64(backoff-half)+1))
+}
+
+// parseRetryAfter accepts both forms of the Retry-After header: a number
+// of seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
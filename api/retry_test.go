@@ -0,0 +1,266 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fastRetries retries immediately, so that tests only wait when the server
+// asks for it with Retry-After.
+var fastRetries = RetryPolicy{
+	MaxAttempts:   4,
+	RetryStatuses: DefaultRetryPolicy.RetryStatuses,
+}
+
+// flakyServer answers with the given responses in turn and then with 200
+// and body. A response is a status code, optionally followed by a space
+// and a Retry-After value, e.g. "503 2".
+func flakyServer(t *testing.T, body string, responses ...string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(hits.Add(1))
+		if n > len(responses) {
+			w.Write([]byte(body))
+			return
+		}
+		code, retryAfter, _ := strings.Cut(responses[n-1], " ")
+		status, err := strconv.Atoi(code)
+		if err != nil {
+			t.Errorf("bad response %q", responses[n-1])
+			status = http.StatusInternalServerError
+		}
+		if retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(`{"message":"try again"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func newTestClient(t *testing.T, baseURL string, policy RetryPolicy, opts ...Option) *Client {
+	t.Helper()
+	opts = append([]Option{WithBaseURL(baseURL), WithCredentials(StaticAPIKey("test-key")), WithRetryPolicy(policy)}, opts...)
+	c, err := NewClient(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func statusOf(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+func TestRetryTransientStatuses(t *testing.T) {
+	for _, status := range []string{"502", "503", "429"} {
+		t.Run(status, func(t *testing.T) {
+			srv, hits := flakyServer(t, `[{"id":"p1","title":"Daily"}]`, status, status)
+			c := newTestClient(t, srv.URL, fastRetries)
+
+			podcasts, err := c.ListPodcasts(context.Background())
+			if err != nil {
+				t.Fatalf("ListPodcasts: %v", err)
+			}
+			if len(podcasts) != 1 || podcasts[0].ID != "p1" {
+				t.Errorf("podcasts = %+v", podcasts)
+			}
+			if got := hits.Load(); got != 3 {
+				t.Errorf("server saw %d attempts, want 3", got)
+			}
+		})
+	}
+}
+
+func TestNoRetryOnClientErrors(t *testing.T) {
+	for _, status := range []string{"400", "401", "404", "422"} {
+		t.Run(status, func(t *testing.T) {
+			srv, hits := flakyServer(t, `[]`, status)
+			c := newTestClient(t, srv.URL, fastRetries)
+
+			_, err := c.ListPodcasts(context.Background())
+			if want, _ := strconv.Atoi(status); statusOf(err) != want {
+				t.Errorf("error = %v, want status %d", err, want)
+			}
+			if got := hits.Load(); got != 1 {
+				t.Errorf("server saw %d attempts, want 1", got)
+			}
+		})
+	}
+}
+
+func TestNoRetryForPostWithoutIdempotencyKey(t *testing.T) {
+	srv, hits := flakyServer(t, `{}`, "503")
+	c := newTestClient(t, srv.URL, fastRetries)
+
+	err := c.do(context.Background(), http.MethodPost, "/podcasts/add-url", []byte(`{}`), nil, "")
+	if statusOf(err) != http.StatusServiceUnavailable {
+		t.Errorf("error = %v, want status 503", err)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("server saw %d attempts, want 1", got)
+	}
+}
+
+func TestRetryPostWithIdempotencyKey(t *testing.T) {
+	var keys []string
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		if hits.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"status":"CREATED","created":"2024-01-01 00:00:00"}`))
+	}))
+	defer srv.Close()
+	c := newTestClient(t, srv.URL, fastRetries)
+
+	item, err := c.AddUrlToPodcast(context.Background(), "p1", "https://www.youtube.com/watch?v=dQw4w9WgXcQ")
+	if err != nil {
+		t.Fatalf("AddUrlToPodcast: %v", err)
+	}
+	if item.Status != StatusCreated {
+		t.Errorf("item = %+v", item)
+	}
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("idempotency keys = %q, want the same key on both attempts", keys)
+	}
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	srv, hits := flakyServer(t, `[]`, "503 1")
+	// Without Retry-After the backoff would take an hour.
+	policy := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Hour, MaxDelay: time.Hour, RetryStatuses: fastRetries.RetryStatuses}
+	c := newTestClient(t, srv.URL, policy)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.ListPodcasts(ctx); err != nil {
+		t.Fatalf("ListPodcasts: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second || elapsed > 5*time.Second {
+		t.Errorf("retried after %s, want about 1s", elapsed)
+	}
+	if got := hits.Load(); got != 2 {
+		t.Errorf("server saw %d attempts, want 2", got)
+	}
+}
+
+func TestRetryAfterHTTPDate(t *testing.T) {
+	// A date in the past means retrying right away.
+	past := time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)
+	srv, hits := flakyServer(t, `[]`, "429 "+past)
+	policy := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Hour, MaxDelay: time.Hour, RetryStatuses: fastRetries.RetryStatuses}
+	c := newTestClient(t, srv.URL, policy)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.ListPodcasts(ctx); err != nil {
+		t.Fatalf("ListPodcasts: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("retried after %s, want right away", elapsed)
+	}
+	if got := hits.Load(); got != 2 {
+		t.Errorf("server saw %d attempts, want 2", got)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"0", 0, true},
+		{"120", 2 * time.Minute, true},
+		{" 5 ", 5 * time.Second, true},
+		{"Wed, 01 May 2024 12:00:30 GMT", 30 * time.Second, true},
+		{"Wed, 01 May 2024 11:59:00 GMT", 0, true},
+		{"", 0, false},
+		{"-1", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %s, %v, want %s, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRetryStopsAtMaxAttempts(t *testing.T) {
+	srv, hits := flakyServer(t, `[]`, "503", "503", "503", "503", "503")
+	policy := fastRetries
+	policy.MaxAttempts = 3
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := newTestClient(t, srv.URL, policy, WithLogger(logger))
+
+	_, err := c.ListPodcasts(context.Background())
+	if statusOf(err) != http.StatusServiceUnavailable {
+		t.Errorf("error = %v, want status 503", err)
+	}
+	if got := hits.Load(); got != 3 {
+		t.Errorf("server saw %d attempts, want 3", got)
+	}
+	if n := strings.Count(logs.String(), "retrying API request"); n != 2 {
+		t.Errorf("logged %d retries, want 2:\n%s", n, logs.String())
+	}
+	if !strings.Contains(logs.String(), "attempts=3") {
+		t.Errorf("debug output does not report the attempt count:\n%s", logs.String())
+	}
+}
+
+func TestRetryGivesUpBeforeDeadline(t *testing.T) {
+	srv, hits := flakyServer(t, `[]`, "503 30")
+	c := newTestClient(t, srv.URL, fastRetries)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err := c.ListPodcasts(ctx)
+	// The server's error is returned right away rather than waiting for
+	// the deadline and reporting it.
+	if statusOf(err) != http.StatusServiceUnavailable {
+		t.Errorf("error = %v, want status 503", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("gave up after %s, want right away", elapsed)
+	}
+	if got := hits.Load(); got != 1 {
+		t.Errorf("server saw %d attempts, want 1", got)
+	}
+}
+
+func TestRetryNetworkErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+	c := newTestClient(t, url, fastRetries)
+
+	_, err := c.ListPodcasts(context.Background())
+	var netErr *NetworkError
+	if !errors.As(err, &netErr) || !Temporary(err) {
+		t.Errorf("error = %v, want a temporary NetworkError", err)
+	}
+}
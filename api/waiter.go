@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"time"
+)
+
+const PollInterval = 3 * time.Second
+
+// HasPending reports whether any of the items is still being processed.
+func HasPending(items []Item) bool {
+	for _, item := range items {
+		if item.Status == StatusCreated {
+			return true
+		}
+	}
+	return false
+}
+
+// AllSucceeded reports whether there is at least one item and every item
+// finished successfully.
+func AllSucceeded(items []Item) bool {
+	if len(items) == 0 {
+		return false
+	}
+	for _, item := range items {
+		if item.Status != StatusSuccess {
+			return false
+		}
+	}
+	return true
+}
+
+// Waiter polls the items of a podcast until they finish processing.
+type Waiter struct {
+	Service   Service
+	PodcastID string
+	// Interval is the time between polls, PollInterval when zero.
+	Interval time.Duration
+}
+
+// Poll fetches the items, after waiting for the interval unless immediate
+// is set. Callers that show every intermediate state, such as the TUI,
+// call it once per refresh.
+func (w Waiter) Poll(ctx context.Context, immediate bool) ([]Item, error) {
+	if !immediate {
+		interval := w.Interval
+		if interval <= 0 {
+			interval = PollInterval
+		}
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return w.Service.GetPodcastItems(ctx, w.PodcastID)
+}
+
+// Wait polls until done returns true or ctx is done. The most recently
+// fetched items are returned in both cases. Polls failing with a Temporary
+// error are tried again at the next interval.
+func (w Waiter) Wait(ctx context.Context, done func([]Item) bool) ([]Item, error) {
+	var items []Item
+	for immediate := true; ; immediate = false {
+		fetched, err := w.Poll(ctx, immediate)
+		if ctx.Err() != nil {
+			return items, ctx.Err()
+		}
+		if Temporary(err) {
+			continue
+		}
+		if err != nil {
+			return items, err
+		}
+		items = fetched
+
+		if done(items) {
+			return items, nil
+		}
+	}
+}
+
+// WaitForItems polls the items of a podcast every interval until done
+// returns true or ctx is done, as described by Waiter.Wait.
+func (c *Client) WaitForItems(ctx context.Context, podcastID string, interval time.Duration, done func([]Item) bool) ([]Item, error) {
+	return Waiter{Service: c, PodcastID: podcastID, Interval: interval}.Wait(ctx, done)
+}
+
+// WaitForItem waits until the item returned by AddUrlToPodcast has left the
+// CREATED state and returns its final form. Items are matched on their
+// creation time; when the server did not report one, WaitForItem waits for
+// every pending item of the podcast and returns the newest.
+func (c *Client) WaitForItem(ctx context.Context, podcastID string, submitted Item, interval time.Duration) (Item, error) {
+	if submitted.Status != StatusCreated {
+		return submitted, nil
+	}
+
+	current := submitted
+	_, err := c.WaitForItems(ctx, podcastID, interval, func(items []Item) bool {
+		if submitted.Created == "" {
+			if sorted := SortItemsByCreated(items); len(sorted) > 0 {
+				current = sorted[0]
+			}
+			return !HasPending(items)
+		}
+
+		for _, item := range items {
+			if item.Created == submitted.Created {
+				current = item
+				return item.Status != StatusCreated
+			}
+		}
+		return false
+	})
+	return current, err
+}
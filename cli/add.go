@@ -0,0 +1,399 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lsherman98/ytrss-cli/api"
+	"github.com/lsherman98/ytrss-cli/history"
+	"github.com/lsherman98/ytrss-cli/youtube"
+)
+
+const (
+	exitItemError = 3
+	exitTimeout   = 4
+)
+
+const (
+	outcomeAccepted  = "accepted"
+	outcomeRejected  = "rejected"
+	outcomeError     = "error"
+	outcomeFailed    = "failed"
+	outcomeDuplicate = "duplicate"
+	outcomeQueued    = "queued"
+)
+
+// entry is a URL to submit along with where it came from. line is zero for
+// URLs given as arguments.
+type entry struct {
+	url    string
+	title  string
+	source string
+	line   int
+}
+
+type submission struct {
+	entry
+	videoID string
+	item    api.Item
+	err     error
+	// queued is set when the API could not be reached and the URL was
+	// queued to be sent later.
+	queued bool
+}
+
+type duplicateError struct {
+	reason string
+}
+
+func (e *duplicateError) Error() string { return e.reason }
+
+func (s submission) outcome() string {
+	var dupErr *duplicateError
+	switch {
+	case errors.As(s.err, &dupErr):
+		return outcomeDuplicate
+	case errors.Is(s.err, youtube.ErrInvalidURL):
+		return outcomeRejected
+	case s.queued:
+		return outcomeQueued
+	case s.err != nil:
+		return outcomeFailed
+	case s.item.Status == api.StatusError:
+		return outcomeError
+	default:
+		return outcomeAccepted
+	}
+}
+
+func runAdd(ctx context.Context, args []string) error {
+	fs := newFlagSet("add", "ytrss add [--podcast <id|title>] [--from-file file|-] [--all] [--allow-duplicates] [--wait [--timeout 30m]] [url...]")
+	podcast := fs.String("podcast", current.defaultPodcast(), "podcast ID or title to add the URLs to (defaults to the profile's default_podcast)")
+	fromFile := fs.String("from-file", "", "read URLs from a file, one per line (- for stdin)")
+	all := fs.Bool("all", false, "submit every recent video of playlist and channel URLs without asking")
+	allowDuplicates := fs.Bool("allow-duplicates", false, "submit videos that are already in the podcast")
+	concurrency := fs.Int("concurrency", 4, "maximum number of URLs submitted at once")
+	wait := fs.Bool("wait", false, "wait until the submitted items finish processing")
+	timeout := fs.Duration("timeout", 30*time.Minute, "maximum time to wait with --wait")
+
+	urls, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if *podcast == "" {
+		return usageError("--podcast is required when the profile has no default_podcast")
+	}
+	if *concurrency < 1 {
+		return usageError("--concurrency must be at least 1")
+	}
+
+	var entries []entry
+	for _, url := range urls {
+		entries = append(entries, entry{url: url})
+	}
+	if *fromFile != "" {
+		fileEntries, err := readURLFile(*fromFile)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, fileEntries...)
+	}
+	if len(entries) == 0 {
+		return usageError("at least one URL is required")
+	}
+
+	p, err := resolvePodcast(ctx, *podcast)
+	if api.Temporary(err) {
+		known, ok := offlinePodcast(*podcast)
+		if !ok {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v, queueing the URLs\n", err)
+		p = known
+	} else if err != nil {
+		return err
+	} else {
+		// The URLs sent from the queue are summed up on stderr, so that
+		// stdout only lists the URLs given to this command.
+		result, err := flushQueue(ctx, false)
+		if sent := len(result.Sent) + len(result.Rejected); sent > 0 {
+			fmt.Fprintf(os.Stderr, "Sent %d queued URLs, %d rejected, see ytrss history\n", sent, len(result.Rejected))
+		}
+		if err != nil && ctx.Err() == nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not send queued URLs: %v\n", err)
+		}
+	}
+
+	entries, err = expandEntries(ctx, entries, *all)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(os.Stderr, "No URLs selected")
+		return nil
+	}
+
+	submissions := prepareSubmissions(entries)
+
+	// The history is opened for each operation rather than for the whole
+	// run, so that other ytrss processes can use it while this one waits
+	// on the API.
+	if !*allowDuplicates {
+		if err := markDuplicates(ctx, p.ID, submissions); err != nil {
+			return err
+		}
+	}
+
+	submitAll(ctx, p, submissions, *concurrency)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	counts := map[string]int{}
+	var submitted []submission
+	for _, s := range submissions {
+		printSubmission(os.Stdout, s)
+		counts[s.outcome()]++
+		// Items the server created in ERROR failed to process just like
+		// those that end in ERROR later, so --wait reports them too.
+		if o := s.outcome(); o == outcomeAccepted || o == outcomeError {
+			submitted = append(submitted, s)
+		}
+	}
+	if *fromFile != "" {
+		fmt.Fprintf(os.Stderr, "%d accepted, %d rejected, %d errored, %d failed, %d duplicates skipped, %d queued\n",
+			counts[outcomeAccepted], counts[outcomeRejected], counts[outcomeError], counts[outcomeFailed], counts[outcomeDuplicate], counts[outcomeQueued])
+	}
+	if counts[outcomeQueued] > 0 {
+		fmt.Fprintf(os.Stderr, "%d URLs were queued and will be sent by the next ytrss add or by ytrss queue flush\n", counts[outcomeQueued])
+	}
+
+	if *wait {
+		if err := waitForSubmissions(ctx, p.ID, submitted, *timeout); err != nil {
+			return err
+		}
+	}
+
+	if notAdded := counts[outcomeRejected] + counts[outcomeError] + counts[outcomeFailed]; notAdded > 0 {
+		return fmt.Errorf("%d of %d URLs could not be added", notAdded, len(entries))
+	}
+	return nil
+}
+
+// readURLFile reads one URL per line, skipping blank lines and lines
+// starting with #.
+func readURLFile(path string) ([]entry, error) {
+	var r io.Reader = os.Stdin
+	source := "stdin"
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+		source = path
+	}
+
+	var entries []entry
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		entries = append(entries, entry{url: text, source: source, line: line})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", source, err)
+	}
+	return entries, nil
+}
+
+// prepareSubmissions normalizes the URL of every entry. Entries that are
+// not valid YouTube video URLs are rejected without contacting the server.
+func prepareSubmissions(entries []entry) []submission {
+	submissions := make([]submission, len(entries))
+	for i, e := range entries {
+		submissions[i].entry = e
+
+		id, err := youtube.VideoID(e.url)
+		if err != nil {
+			submissions[i].err = err
+			continue
+		}
+		submissions[i].url = youtube.WatchURL(id)
+		submissions[i].videoID = id
+	}
+	return submissions
+}
+
+// markDuplicates flags submissions whose video is already in the podcast
+// according to its items and the submission history, or that appear more
+// than once in the same run. When the API is unreachable only the history
+// is checked, and when the history is unavailable only the items.
+func markDuplicates(ctx context.Context, podcastID string, submissions []submission) error {
+	items, itemsErr := api.GetPodcastItems(ctx, podcastID)
+	if itemsErr != nil && !api.Temporary(itemsErr) {
+		return fmt.Errorf("checking for duplicates: %w (use --allow-duplicates to skip the check)", itemsErr)
+	}
+
+	store, err := history.OpenDefault()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: submission history unavailable: %v\n", err)
+	} else {
+		defer store.Close()
+	}
+	if itemsErr != nil {
+		if store == nil {
+			return fmt.Errorf("checking for duplicates: %w (use --allow-duplicates to skip the check)", itemsErr)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: checking for duplicates in the history only: %v\n", itemsErr)
+	}
+
+	var pending []*submission
+	var candidates []history.Candidate
+	for i := range submissions {
+		if submissions[i].err == nil {
+			pending = append(pending, &submissions[i])
+			candidates = append(candidates, history.Candidate{VideoID: submissions[i].videoID, Title: submissions[i].title})
+		}
+	}
+
+	reasons, err := history.CheckAll(store, podcastID, items, candidates)
+	if err != nil {
+		return err
+	}
+	for i, reason := range reasons {
+		if reason != "" {
+			pending[i].err = &duplicateError{reason: reason}
+		}
+	}
+	return nil
+}
+
+// submitAll adds every pending submission to the podcast using at most
+// concurrency requests at a time and records them in the history.
+// Submissions that fail because the API is unreachable are queued instead.
+func submitAll(ctx context.Context, podcast api.Podcast, submissions []submission, concurrency int) {
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i := range submissions {
+		if submissions[i].err != nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			submissions[i].err = ctx.Err()
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(s *submission) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			s.item, s.err = api.AddUrlToPodcast(ctx, podcast.ID, s.url)
+			if ctx.Err() != nil {
+				return
+			}
+			sub := history.Submission{URL: s.url, VideoID: s.videoID, Podcast: podcast, Profile: current.profile}
+			if api.Temporary(s.err) {
+				err := history.QueueSubmission(sub, s.err)
+				if err == nil {
+					s.queued = true
+					return
+				}
+				fmt.Fprintf(os.Stderr, "Warning: could not queue %s: %v\n", s.url, err)
+			}
+			if err := history.RecordSubmission(sub, s.item, s.err); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not record %s in history: %v\n", s.url, err)
+			}
+		}(&submissions[i])
+	}
+
+	wg.Wait()
+}
+
+// waitForSubmissions blocks until every submission has finished processing,
+// recording the final status in the history. Items that end in ERROR are
+// reported with exitItemError, and running out of time with exitTimeout.
+func waitForSubmissions(ctx context.Context, podcastID string, submitted []submission, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	itemErrors := 0
+	for _, s := range submitted {
+		item, err := api.WaitForItem(ctx, podcastID, s.item, current.settings.PollInterval)
+		// A single request timing out also matches DeadlineExceeded, so
+		// only the wait's own deadline counts as running out of time.
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			printItem(os.Stdout, s.url, item)
+			return &exitError{code: exitTimeout, err: fmt.Errorf("timed out after %s waiting for %s", timeout, s.url)}
+		}
+		if err != nil {
+			return err
+		}
+		history.UpdateDefault(podcastID, []api.Item{item})
+
+		printItem(os.Stdout, s.url, item)
+		if item.Status == api.StatusError {
+			itemErrors++
+		}
+	}
+
+	if itemErrors > 0 {
+		return &exitError{code: exitItemError, err: fmt.Errorf("%d of %d items failed to process", itemErrors, len(submitted))}
+	}
+	return nil
+}
+
+func printSubmission(w io.Writer, s submission) {
+	if s.line > 0 {
+		fmt.Fprintf(w, "%s:%d\t", s.source, s.line)
+	}
+	if s.err != nil {
+		fmt.Fprintf(w, "%s\t%s\t%v\n", s.outcome(), s.url, s.err)
+		return
+	}
+	fmt.Fprintf(w, "%s\t", s.outcome())
+	printItem(w, s.url, s.item)
+}
+
+func printItem(w io.Writer, url string, item api.Item) {
+	title := item.Title
+	if title == "" {
+		title = "-"
+	}
+	fmt.Fprintf(w, "%s\t%s\t%s\n", item.Status, title, url)
+	if item.Error != "" {
+		fmt.Fprintf(w, "\terror: %s\n", item.Error)
+	}
+}
+
+// offlinePodcast finds the podcast among those the profile submitted to
+// when the API cannot be reached to resolve it.
+func offlinePodcast(idOrTitle string) (api.Podcast, bool) {
+	store, err := history.OpenDefault()
+	if err != nil {
+		return api.Podcast{}, false
+	}
+	defer store.Close()
+
+	podcasts, err := store.Podcasts(current.profile)
+	if err != nil {
+		return api.Podcast{}, false
+	}
+	p, err := matchPodcast(podcasts, idOrTitle)
+	return p, err == nil
+}
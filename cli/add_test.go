@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lsherman98/ytrss-cli/api"
+	"github.com/lsherman98/ytrss-cli/config"
+	"github.com/lsherman98/ytrss-cli/youtube"
+)
+
+// fakeAPI serves podcast p1 with no items and answers every submission
+// with added.
+func fakeAPI(t *testing.T, added string) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/list-podcasts":
+			w.Write([]byte(`[{"id":"p1","title":"Daily"}]`))
+		case "/get-items/p1":
+			w.Write([]byte(`[]`))
+		case "/podcasts/add-url":
+			w.Write([]byte(added))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client, err := api.NewClient(api.WithBaseURL(srv.URL), api.WithCredentials(api.StaticAPIKey("test-key")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, savedSession := api.Default(), current
+	t.Cleanup(func() { api.SetDefault(saved); current = savedSession })
+	api.SetDefault(client)
+	current = &session{
+		file:     &config.Config{},
+		profile:  api.DefaultProfile,
+		settings: &config.Config{PollInterval: 10 * time.Millisecond},
+	}
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+}
+
+// captureStdout returns what f prints to stdout.
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = saved }()
+
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+	f()
+	w.Close()
+	return <-done
+}
+
+func TestSubmissionOutcome(t *testing.T) {
+	tests := []struct {
+		s    submission
+		want string
+	}{
+		{submission{item: api.Item{Status: api.StatusCreated}}, outcomeAccepted},
+		{submission{item: api.Item{Status: api.StatusError}}, outcomeError},
+		{submission{err: youtube.ErrInvalidURL}, outcomeRejected},
+		{submission{err: &duplicateError{reason: "already submitted"}}, outcomeDuplicate},
+		{submission{err: errors.New("boom"), queued: true}, outcomeQueued},
+		{submission{err: errors.New("boom")}, outcomeFailed},
+	}
+	for _, tt := range tests {
+		if got := tt.s.outcome(); got != tt.want {
+			t.Errorf("outcome of %+v = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestAddWaitReportsItemCreatedInError(t *testing.T) {
+	fakeAPI(t, `{"status":"ERROR","title":"Private video","error":"video is private","created":"2024-05-01 12:00:00"}`)
+
+	var err error
+	out := captureStdout(t, func() {
+		err = runAdd(context.Background(), []string{"--podcast", "p1", "--wait", "https://youtu.be/dQw4w9WgXcQ"})
+	})
+
+	var exitErr *exitError
+	if !errors.As(err, &exitErr) || exitErr.code != exitItemError {
+		t.Fatalf("runAdd = %v, want exit code %d", err, exitItemError)
+	}
+	if !strings.HasPrefix(out, outcomeError+"\t") || !strings.Contains(out, "error: video is private") {
+		t.Errorf("output does not report the item's error:\n%s", out)
+	}
+}
+
+func TestAddReportsItemCreatedInErrorWithoutWait(t *testing.T) {
+	fakeAPI(t, `{"status":"ERROR","error":"video is private","created":"2024-05-01 12:00:00"}`)
+
+	var err error
+	captureStdout(t, func() {
+		err = runAdd(context.Background(), []string{"--podcast", "p1", "https://youtu.be/dQw4w9WgXcQ"})
+	})
+
+	var exitErr *exitError
+	if err == nil || errors.As(err, &exitErr) {
+		t.Errorf("runAdd = %v, want a plain failure", err)
+	}
+}
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/lsherman98/ytrss-cli/api"
+)
+
+func runAuth(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageError("usage: ytrss auth login|verify|status")
+	}
+
+	switch args[0] {
+	case "login":
+		return runAuthLogin(ctx, args[1:])
+	case "verify":
+		return runAuthVerify(ctx, args[1:])
+	case "status":
+		return runAuthStatus(ctx, args[1:])
+	}
+	return usageError("unknown auth command %q", args[0])
+}
+
+// runAuthLogin reads an API key from the terminal or stdin, checks it
+// with the server and stores it in the keyring of the selected profile.
+func runAuthLogin(ctx context.Context, args []string) error {
+	fs := newFlagSet("auth login", "ytrss auth login [--no-verify] < key")
+	noVerify := fs.Bool("no-verify", false, "store the key without checking it with the server")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageError("the API key is read from stdin, not from arguments")
+	}
+
+	key, err := readAPIKey()
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		return usageError("no API key given")
+	}
+
+	if !*noVerify {
+		usage, err := api.VerifyAPIKey(ctx, key)
+		if errors.Is(err, api.ErrUnauthorized) {
+			return fmt.Errorf("the server rejected this API key, it was not saved")
+		}
+		if err != nil {
+			return fmt.Errorf("could not verify the API key: %w (use --no-verify to save it anyway)", err)
+		}
+		fmt.Fprintf(os.Stderr, "API key verified. Usage: %s\n", usage)
+	}
+
+	if err := api.SetApiKey(current.profile, key); err != nil {
+		return fmt.Errorf("saving API key: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "API key saved for profile %s\n", current.profile)
+	return nil
+}
+
+// readAPIKey prompts for the key without echoing it when stdin is a
+// terminal, and otherwise reads the first line of stdin.
+func readAPIKey() (string, error) {
+	if isTerminal(os.Stdin) {
+		fmt.Fprint(os.Stderr, "API key: ")
+		key, err := term.ReadPassword(os.Stdin.Fd())
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(key)), nil
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("reading stdin: %w", err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// runAuthVerify checks the API key the selected profile would use.
+func runAuthVerify(ctx context.Context, args []string) error {
+	fs := newFlagSet("auth verify", "ytrss auth verify")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	// The client caches the key it resolves, so the credentials are only
+	// read once.
+	client := api.Default()
+	_, source, err := client.ResolveAPIKey(ctx)
+	if err != nil {
+		return err
+	}
+	usage, err := client.GetUsage(ctx)
+	if err != nil {
+		return fmt.Errorf("API key from %s: %w", describe(source), err)
+	}
+	fmt.Printf("API key from %s is valid for profile %s\n", describe(source), current.profile)
+	fmt.Printf("Usage: %s\n", usage)
+	return nil
+}
+
+// credentialChain returns the credential sources of the default client.
+func credentialChain() api.CredentialChain {
+	creds := api.Default().Credentials()
+	if chain, ok := creds.(api.CredentialChain); ok {
+		return chain
+	}
+	return api.CredentialChain{creds}
+}
+
+// runAuthStatus lists every credential source in lookup order and which
+// one supplies the API key.
+func runAuthStatus(ctx context.Context, args []string) error {
+	fs := newFlagSet("auth status", "ytrss auth status")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	fmt.Printf("Profile: %s\n\n", current.profile)
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "SOURCE\tSTATUS\tKEY")
+
+	// Each source is read once. The first one with a key is in use, as in
+	// CredentialChain.Resolve, unless an earlier source failed.
+	err := api.ErrNoAPIKey
+	resolved := false
+	for _, p := range credentialChain() {
+		key, pErr := p.APIKey(ctx)
+		status := "available"
+		switch {
+		case errors.Is(pErr, api.ErrNoAPIKey):
+			status, key = "not set", ""
+		case pErr != nil:
+			status, key = "error: "+pErr.Error(), ""
+			if !resolved {
+				resolved, err = true, pErr
+			}
+		case !resolved:
+			status = "in use"
+			resolved, err = true, nil
+		}
+		if configured(p) {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", describe(p), status, maskKey(key))
+		}
+	}
+	tw.Flush()
+	return err
+}
+
+// configured reports whether the provider is worth listing; file and
+// command sources only exist when set up.
+func configured(p api.CredentialProvider) bool {
+	switch p := p.(type) {
+	case api.FileCredentials:
+		return p.Path != ""
+	case api.CommandCredentials:
+		return p.Command != ""
+	}
+	return true
+}
+
+func describe(p api.CredentialProvider) string {
+	if s, ok := p.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", p)
+}
+
+func maskKey(key string) string {
+	switch {
+	case key == "":
+		return "-"
+	case len(key) <= 8:
+		return "****"
+	}
+	return key[:4] + "…" + key[len(key)-4:]
+}
@@ -0,0 +1,325 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/lsherman98/ytrss-cli/api"
+	"github.com/lsherman98/ytrss-cli/config"
+)
+
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+type exitError struct {
+	code int
+	err  error
+	// quiet suppresses printing err, e.g. when the flag package already did.
+	quiet bool
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+func usageError(format string, args ...any) error {
+	return &exitError{code: exitUsage, err: fmt.Errorf(format, args...)}
+}
+
+func commands() []command {
+	return []command{
+		{name: "add", summary: "Add one or more YouTube URLs to a podcast", run: runAdd},
+		{name: "podcasts", summary: "List your podcasts", run: runPodcasts},
+		{name: "items", summary: "List the items of a podcast", run: runItems},
+		{name: "history", summary: "List URLs submitted from this machine", run: runHistory},
+		{name: "queue", summary: "List, send or drop URLs queued while offline", run: runQueue},
+		{name: "auth", summary: "Log in and check the API key", run: runAuth},
+		{name: "config", summary: "Read and change settings", run: runConfig},
+		{name: "update", summary: "Update ytrss to the latest or a given version", run: runUpdate},
+		{name: "version", summary: "Show version and build information", run: runVersion},
+	}
+}
+
+// BuildInfo describes the running binary, as set by the release build.
+type BuildInfo struct {
+	Version string
+	Commit  string
+	Date    string
+}
+
+type globalOptions struct {
+	profile           string
+	apiURL            string
+	apiKeyFile        string
+	allowInsecureHTTP bool
+	requestTimeout    time.Duration
+	overallTimeout    time.Duration
+	retries           int
+	debug             bool
+}
+
+// globalFlagSet defines the global flags. Flags named after a config key,
+// such as --api-url for api_url, override that key when given.
+func globalFlagSet(opts *globalOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet("ytrss", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&opts.profile, "profile", "", "account profile to use (or set YTRSS_PROFILE or profile in the config file)")
+	fs.StringVar(&opts.apiURL, "api-url", "", "base URL of the API (or set YTRSS_API_URL or api_url in the config file)")
+	fs.StringVar(&opts.apiKeyFile, "api-key-file", "", "read the API key from a file (or set YTRSS_API_KEY_FILE or api_key_file in the config file)")
+	fs.BoolVar(&opts.allowInsecureHTTP, "allow-insecure-http", false, "allow sending the API key over plain HTTP to non-loopback hosts")
+	fs.DurationVar(&opts.requestTimeout, "request-timeout", api.DefaultRequestTimeout, "maximum duration of a single API request (0 for no limit)")
+	fs.DurationVar(&opts.overallTimeout, "overall-timeout", 0, "maximum duration of the whole command (0 for no limit)")
+	fs.IntVar(&opts.retries, "retries", api.DefaultRetryPolicy.MaxAttempts-1, "number of times a failed API request is retried")
+	fs.BoolVar(&opts.debug, "debug", os.Getenv("YTRSS_DEBUG") != "", "log API requests and retries to stderr (or set YTRSS_DEBUG)")
+	return fs
+}
+
+// Run executes the command in args and returns the process exit code.
+// Without a command it starts the interactive interface.
+func Run(args []string, build BuildInfo) int {
+	var opts globalOptions
+	fs := globalFlagSet(&opts)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			printUsage(os.Stdout)
+			return exitOK
+		}
+		fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+		printUsage(os.Stderr)
+		return exitUsage
+	}
+	args = fs.Args()
+
+	flags := map[string]string{}
+	fs.Visit(func(f *flag.Flag) { flags[f.Name] = f.Value.String() })
+
+	// The config commands must keep working when the config file is
+	// invalid, so that it can be fixed.
+	if err := configure(flags, opts.debug, build); err != nil && (len(args) == 0 || args[0] != "config") {
+		return exitCode(err)
+	}
+
+	if len(args) == 0 {
+		return runTUI(current)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if opts.overallTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.overallTimeout)
+		defer cancel()
+	}
+
+	name, rest := args[0], args[1:]
+	switch name {
+	case "help", "-h", "--help":
+		printUsage(os.Stdout)
+		return exitOK
+	}
+
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return exitCode(cmd.run(ctx, rest))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	printUsage(os.Stderr)
+	return exitUsage
+}
+
+// session holds the settings shared by every command of a run.
+type session struct {
+	// file is the config file as written and flags the global flags given
+	// on the command line, by name.
+	file  *config.Config
+	flags map[string]string
+	debug bool
+	build BuildInfo
+
+	profile  string
+	settings *config.Config
+}
+
+// current is the session configured by Run.
+var current *session
+
+// configure loads the config file, selects the profile and sets up the
+// default API client for it.
+func configure(flags map[string]string, debug bool, build BuildInfo) error {
+	current = &session{file: &config.Config{}, flags: flags, debug: debug, build: build}
+
+	file, err := config.Load()
+	if err != nil {
+		return err
+	}
+	current.file = file
+
+	settings, err := current.resolve("")
+	if err != nil {
+		return usageError("%v", err)
+	}
+	current.profile = settings.Profile
+	current.settings = settings
+
+	client, err := current.client(settings)
+	if err != nil {
+		return usageError("%v", err)
+	}
+	api.SetDefault(client)
+	return nil
+}
+
+// resolve returns the effective settings for profile, or for the selected
+// profile if it is empty.
+func (s *session) resolve(profile string) (*config.Config, error) {
+	flags := s.flags
+	if profile != "" {
+		flags = maps.Clone(s.flags)
+		flags["profile"] = profile
+	}
+	return s.file.Resolve(flags)
+}
+
+// client returns an API client configured with settings.
+func (s *session) client(settings *config.Config) (*api.Client, error) {
+	retry := api.DefaultRetryPolicy
+	retry.MaxAttempts = *settings.Retries + 1
+
+	clientOpts := []api.Option{
+		api.WithBaseURL(settings.APIURL),
+		api.WithCredentials(api.DefaultCredentials(settings.Profile, settings.APIKeyFile, settings.APIKeyCommand)),
+		api.WithInsecureHTTP(settings.AllowInsecureHTTP),
+		api.WithRetryPolicy(retry),
+		api.WithRequestTimeout(settings.RequestTimeout),
+		api.WithUserAgent(api.DefaultUserAgent + "/" + s.build.Version),
+	}
+	if s.debug {
+		clientOpts = append(clientOpts, api.WithLogger(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	}
+	return api.NewClient(clientOpts...)
+}
+
+// defaultPodcast returns the default podcast of the selected profile, or
+// "" if it has none.
+func (s *session) defaultPodcast() string {
+	return s.settings.DefaultPodcast
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func exitCode(err error) int {
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		if !exitErr.quiet {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		return exitErr.code
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	if h := hint(err); h != "" {
+		fmt.Fprintf(os.Stderr, "Hint: %s\n", h)
+	}
+	return exitFailure
+}
+
+func hint(err error) string {
+	var netErr *api.NetworkError
+	switch {
+	case errors.Is(err, api.ErrNoAPIKey):
+		return "run ytrss auth login to set an API key, or set " + api.APIKeyEnv
+	case errors.Is(err, api.ErrUnauthorized):
+		return "the API key is invalid, run ytrss auth login to replace it or ytrss auth status to see where it comes from"
+	case errors.Is(err, api.ErrQuotaExceeded):
+		return "wait for your usage to reset or upgrade your plan"
+	case errors.As(err, &netErr):
+		return "check your network connection, or retry with --retries and --request-timeout"
+	}
+	return ""
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: ytrss [global flags] [command] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run without a command to start the interactive interface.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-12s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Global flags:")
+	fs := globalFlagSet(&globalOptions{})
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+}
+
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s\n", usage)
+		var hasFlags bool
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(fs.Output())
+			fmt.Fprintln(fs.Output(), "Flags:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+// parseFlags parses flags that may appear anywhere among the positional
+// arguments and returns the positional arguments in order.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, &exitError{code: exitUsage, err: err, quiet: true}
+		}
+
+		rest := fs.Args()
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
+
+	"github.com/lsherman98/ytrss-cli/config"
+)
+
+func runConfig(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageError("usage: ytrss config get|set|list|edit|path")
+	}
+
+	switch args[0] {
+	case "get":
+		return runConfigGet(args[1:])
+	case "set":
+		return runConfigSet(args[1:])
+	case "list":
+		return runConfigList(args[1:])
+	case "edit":
+		return runConfigEdit(ctx, args[1:])
+	case "path":
+		return runConfigPath(args[1:])
+	}
+	return usageError("unknown config command %q", args[0])
+}
+
+// runConfigGet prints the effective value of a key. Keys of profiles are
+// printed as written in the config file.
+func runConfigGet(args []string) error {
+	fs := newFlagSet("config get", "ytrss config get <key>")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError("expected exactly one key")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	key := positional[0]
+	if slices.Contains(config.Keys(), key) {
+		value, _ := cfg.Lookup(key, current.flags)
+		fmt.Println(value)
+		return nil
+	}
+	value, err := cfg.Get(key)
+	if err != nil {
+		return usageError("%v", err)
+	}
+	fmt.Println(value)
+	return nil
+}
+
+// runConfigSet stores a value in the config file. An empty value removes
+// the key.
+func runConfigSet(args []string) error {
+	fs := newFlagSet("config set", "ytrss config set <key> <value>")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 2 {
+		return usageError("expected a key and a value")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Set(positional[0], positional[1]); err != nil {
+		return usageError("%v", err)
+	}
+	return config.Save(cfg)
+}
+
+// runConfigList prints every key with its effective value and where the
+// value comes from, followed by the settings of each profile.
+func runConfigList(args []string) error {
+	fs := newFlagSet("config list", "ytrss config list")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+	for _, key := range config.Keys() {
+		value, source := cfg.Lookup(key, current.flags)
+		if value == "" {
+			value = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", key, value, source)
+	}
+	for _, name := range cfg.ProfileNames() {
+		for _, key := range config.ProfileKeys() {
+			full := "profiles." + name + "." + key
+			if value, _ := cfg.Get(full); value != "" {
+				fmt.Fprintf(tw, "%s\t%s\t%s\n", full, value, "file")
+			}
+		}
+	}
+	return tw.Flush()
+}
+
+// runConfigEdit opens the config file in $VISUAL or $EDITOR and checks it
+// once the editor exits.
+func runConfigEdit(ctx context.Context, args []string) error {
+	fs := newFlagSet("config edit", "ytrss config edit")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	path, err := config.Path()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(configTemplate()), 0o600); err != nil {
+			return err
+		}
+	}
+
+	editor := firstNonEmpty(os.Getenv("VISUAL"), os.Getenv("EDITOR"), "vi")
+	cmd := exec.CommandContext(ctx, "sh", "-c", editor+` "$1"`, "sh", path)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running %s: %w", editor, err)
+	}
+
+	if _, err := config.Load(); err != nil {
+		return fmt.Errorf("%w\nrun ytrss config edit again to fix it", err)
+	}
+	return nil
+}
+
+func configTemplate() string {
+	s := "# ytrss configuration. Every key is optional.\n#\n"
+	for _, key := range config.Keys() {
+		s += fmt.Sprintf("# %s: %s (env %s)\n", key, config.Usage(key), config.EnvVar(key))
+	}
+	s += "#\n# profiles:\n#   work:\n#     api_url: https://ytrss.example.com/api/v1\n#     default_podcast: Daily\n"
+	return s
+}
+
+func runConfigPath(args []string) error {
+	fs := newFlagSet("config path", "ytrss config path")
+	if _, err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	path, err := config.Path()
+	if err != nil {
+		return err
+	}
+	fmt.Println(path)
+	return nil
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lsherman98/ytrss-cli/youtube"
+)
+
+var feedFetcher youtube.Fetcher = youtube.HTTPFetcher{Client: &http.Client{Timeout: 30 * time.Second}}
+
+// expandEntries replaces playlist and channel URLs with the videos they
+// contain. Unless all is set, the user picks the videos to keep.
+func expandEntries(ctx context.Context, entries []entry, all bool) ([]entry, error) {
+	var expanded []entry
+	for _, e := range entries {
+		collection, err := youtube.ParseCollection(e.url)
+		if errors.Is(err, youtube.ErrNotCollection) || errors.Is(err, youtube.ErrInvalidURL) {
+			expanded = append(expanded, e)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		title, videos, err := youtube.Expand(ctx, feedFetcher, collection)
+		if err != nil {
+			return nil, fmt.Errorf("expanding %s: %w", e.url, err)
+		}
+		if len(videos) == 0 {
+			fmt.Fprintf(os.Stderr, "%s: no videos found\n", e.url)
+			continue
+		}
+
+		selected := videos
+		if !all {
+			selected, err = selectVideos(os.Stdin, os.Stderr, collection, title, videos)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, v := range selected {
+			expanded = append(expanded, entry{url: v.URL, title: v.Title, source: e.source, line: e.line})
+		}
+	}
+	return expanded, nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func selectVideos(in *os.File, out io.Writer, c youtube.Collection, title string, videos []youtube.FeedVideo) ([]youtube.FeedVideo, error) {
+	if !isTerminal(in) {
+		return nil, usageError("%s needs a terminal to choose videos, pass --all to submit all %d", c, len(videos))
+	}
+
+	if title == "" {
+		title = c.String()
+	}
+	fmt.Fprintf(out, "%s (%d videos):\n", title, len(videos))
+	for i, v := range videos {
+		published := ""
+		if !v.Published.IsZero() {
+			published = " (" + v.Published.Local().Format("Jan 2, 2006") + ")"
+		}
+		fmt.Fprintf(out, "  %2d. %s%s\n", i+1, v.Title, published)
+	}
+
+	reader := bufio.NewReader(in)
+	for {
+		fmt.Fprint(out, "Videos to submit [all, none, or e.g. 1,3-5]: ")
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			return nil, err
+		}
+
+		indexes, err := parseSelection(line, len(videos))
+		if err != nil {
+			fmt.Fprintf(out, "%v\n", err)
+			continue
+		}
+
+		selected := make([]youtube.FeedVideo, len(indexes))
+		for i, idx := range indexes {
+			selected[i] = videos[idx]
+		}
+		return selected, nil
+	}
+}
+
+// parseSelection parses a comma separated list of 1-based numbers and
+// ranges into 0-based indexes below n.
+func parseSelection(input string, n int) ([]int, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	switch input {
+	case "all", "a", "*":
+		indexes := make([]int, n)
+		for i := range indexes {
+			indexes[i] = i
+		}
+		return indexes, nil
+	case "none", "", "n":
+		return nil, nil
+	}
+
+	seen := make(map[int]bool)
+	var indexes []int
+	for _, part := range strings.Split(input, ",") {
+		part = strings.TrimSpace(part)
+		lo, hi, isRange := strings.Cut(part, "-")
+		if !isRange {
+			hi = lo
+		}
+
+		start, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number or range", part)
+		}
+		end, err := strconv.Atoi(strings.TrimSpace(hi))
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number or range", part)
+		}
+		if start < 1 || end > n || start > end {
+			return nil, fmt.Errorf("%q is outside 1-%d", part, n)
+		}
+
+		for i := start - 1; i < end; i++ {
+			if !seen[i] {
+				seen[i] = true
+				indexes = append(indexes, i)
+			}
+		}
+	}
+	return indexes, nil
+}
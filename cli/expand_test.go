@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lsherman98/ytrss-cli/youtube"
+)
+
+func TestParseSelection(t *testing.T) {
+	tests := []struct {
+		input   string
+		n       int
+		want    []int
+		wantErr bool
+	}{
+		{input: "all", n: 3, want: []int{0, 1, 2}},
+		{input: " * \n", n: 2, want: []int{0, 1}},
+		{input: "none", n: 3, want: nil},
+		{input: "\n", n: 3, want: nil},
+		{input: "2", n: 3, want: []int{1}},
+		{input: "1,3-5", n: 5, want: []int{0, 2, 3, 4}},
+		{input: " 4 - 5 , 1 ", n: 5, want: []int{3, 4, 0}},
+		{input: "3-3", n: 3, want: []int{2}},
+
+		// Duplicates and overlapping ranges are kept once, in the order
+		// first given.
+		{input: "2,2,1-3,3", n: 3, want: []int{1, 0, 2}},
+
+		{input: "0", n: 3, wantErr: true},
+		{input: "4", n: 3, wantErr: true},
+		{input: "2-4", n: 3, wantErr: true},
+		{input: "3-1", n: 3, wantErr: true},
+		{input: "-1", n: 3, wantErr: true},
+		{input: "1,x", n: 3, wantErr: true},
+		{input: "1-", n: 3, wantErr: true},
+		{input: "1,,2", n: 3, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseSelection(tt.input, tt.n)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseSelection(%q, %d) = %v, want an error", tt.input, tt.n, got)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("parseSelection(%q, %d) = %v, %v, want %v", tt.input, tt.n, got, err, tt.want)
+		}
+	}
+}
+
+type stringFetcher string
+
+func (f stringFetcher) Fetch(ctx context.Context, feedURL string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(string(f))), nil
+}
+
+func TestExpandEntries(t *testing.T) {
+	defer func(saved youtube.Fetcher) { feedFetcher = saved }(feedFetcher)
+	feedFetcher = stringFetcher(`<feed xmlns:yt="http://www.youtube.com/xml/schemas/2015" xmlns="http://www.w3.org/2005/Atom">
+ <title>Talks</title>
+ <entry><yt:videoId>dQw4w9WgXcQ</yt:videoId><title>One</title></entry>
+ <entry><yt:videoId>9bZkp7q19f0</yt:videoId><title>Two</title></entry>
+</feed>`)
+
+	entries := []entry{
+		{url: "https://youtu.be/aqz-KE-bpKQ"},
+		{url: "https://www.youtube.com/playlist?list=PLabc123", source: "urls.txt", line: 3},
+	}
+	got, err := expandEntries(context.Background(), entries, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []entry{
+		{url: "https://youtu.be/aqz-KE-bpKQ"},
+		{url: "https://www.youtube.com/watch?v=dQw4w9WgXcQ", title: "One", source: "urls.txt", line: 3},
+		{url: "https://www.youtube.com/watch?v=9bZkp7q19f0", title: "Two", source: "urls.txt", line: 3},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("expandEntries = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lsherman98/ytrss-cli/history"
+)
+
+var historyHeader = []string{"SUBMITTED", "STATUS", "PODCAST", "TITLE", "URL", "ERROR"}
+
+func runHistory(ctx context.Context, args []string) error {
+	fs := newFlagSet("history", "ytrss history [--podcast <id|title>] [--since 7d|2006-01-02] [--status status] [--output format]")
+	podcast := fs.String("podcast", "", "only show submissions to this podcast ID or title")
+	since := fs.String("since", "", "only show submissions since a duration ago, such as 12h or 7d, or a date")
+	status := fs.String("status", "", "only show submissions with this status: "+strings.ToLower(strings.Join(history.Statuses, ", ")))
+	output := outputFlag(fs)
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageError("unexpected arguments: %v", positional)
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	filter := history.Filter{Profile: current.profile, Podcast: *podcast}
+	if *since != "" {
+		if filter.Since, err = parseSince(*since, time.Now()); err != nil {
+			return usageError("--since: %v", err)
+		}
+	}
+	if *status != "" {
+		if !slices.ContainsFunc(history.Statuses, func(s string) bool { return strings.EqualFold(s, *status) }) {
+			return usageError("--status: unknown status %q, use one of %s", *status, strings.ToLower(strings.Join(history.Statuses, ", ")))
+		}
+		filter.Status = *status
+	}
+
+	store, err := history.OpenDefault()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	records, err := store.Query(filter)
+	if err != nil {
+		return err
+	}
+	return render(os.Stdout, *output, historyHeader, records, func(r history.Record) []string {
+		return historyRow(r, *output == outputTable)
+	})
+}
+
+// parseSince accepts a duration before now, with d for days, or a date in
+// local time.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.DateOnly, time.DateTime, time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is neither a duration such as 12h or 7d nor a date such as 2006-01-02", value)
+}
+
+func historyRow(r history.Record, human bool) []string {
+	if !human {
+		return []string{r.Submitted.Format(time.RFC3339), r.Status(), r.PodcastID, r.Item.Title, r.URL, firstNonEmpty(r.Error, r.Item.Error)}
+	}
+
+	podcast := firstNonEmpty(r.PodcastTitle, r.PodcastID)
+	title := firstNonEmpty(r.Item.Title, "-")
+	errText := firstNonEmpty(r.Error, r.Item.Error, "-")
+	return []string{r.Submitted.Local().Format("Jan 2, 2006 3:04 PM"), r.Status(), podcast, title, r.URL, errText}
+}
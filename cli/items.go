@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"context"
+	"os"
+
+	"github.com/lsherman98/ytrss-cli/api"
+	"github.com/lsherman98/ytrss-cli/history"
+)
+
+var itemHeader = []string{"TITLE", "STATUS", "CREATED", "ERROR"}
+
+func runItems(ctx context.Context, args []string) error {
+	fs := newFlagSet("items", "ytrss items [--output format] [podcast id|title]")
+	output := outputFlag(fs)
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+	podcast := current.defaultPodcast()
+	switch {
+	case len(positional) == 1:
+		podcast = positional[0]
+	case len(positional) > 1:
+		return usageError("expected at most one podcast")
+	case podcast == "":
+		return usageError("expected a podcast, or set default_podcast in the profile")
+	}
+
+	p, err := resolvePodcast(ctx, podcast)
+	if err != nil {
+		return err
+	}
+
+	items, err := api.GetPodcastItems(ctx, p.ID)
+	if err != nil {
+		return err
+	}
+	// The history is best effort, so failing to update it is ignored.
+	history.UpdateDefault(p.ID, items)
+
+	return render(os.Stdout, *output, itemHeader, api.SortItemsByCreated(items), func(item api.Item) []string {
+		return itemRow(item, *output == outputTable)
+	})
+}
+
+// itemRow formats an item for tabular output. Human-readable rows use local
+// times and placeholders; machine-readable rows keep the raw values.
+func itemRow(item api.Item, human bool) []string {
+	if !human {
+		return []string{item.Title, item.Status, item.Created, item.Error}
+	}
+
+	title := item.Title
+	if title == "" {
+		title = "-"
+	}
+
+	created := "-"
+	if t := api.ParseCreatedTime(item.Created); !t.IsZero() {
+		created = t.Local().Format("Jan 2, 2006 3:04 PM")
+	} else if item.Created != "" {
+		created = item.Created
+	}
+
+	errText := item.Error
+	if errText == "" {
+		errText = "-"
+	}
+
+	return []string{title, item.Status, created, errText}
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	outputTable  = "table"
+	outputJSON   = "json"
+	outputNDJSON = "ndjson"
+	outputCSV    = "csv"
+	outputTSV    = "tsv"
+)
+
+var outputFormats = []string{outputTable, outputJSON, outputNDJSON, outputCSV, outputTSV}
+
+func outputFlag(fs *flag.FlagSet) *string {
+	return fs.String("output", outputTable, "output format: "+strings.Join(outputFormats, "|"))
+}
+
+func validateOutput(format string) error {
+	for _, f := range outputFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return usageError("unknown output format %q (expected %s)", format, strings.Join(outputFormats, ", "))
+}
+
+// render writes records in the requested format. Structured formats encode
+// the records directly; tabular formats use header and row.
+func render[T any](w io.Writer, format string, header []string, records []T, row func(T) []string) error {
+	switch format {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if records == nil {
+			records = []T{}
+		}
+		return enc.Encode(records)
+
+	case outputNDJSON:
+		enc := json.NewEncoder(w)
+		for _, r := range records {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case outputCSV, outputTSV:
+		cw := csv.NewWriter(w)
+		if format == outputTSV {
+			cw.Comma = '\t'
+		}
+		if err := cw.Write(header); err != nil {
+			return err
+		}
+		for _, r := range records {
+			if err := cw.Write(row(r)); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+
+	case outputTable:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+		for _, r := range records {
+			fmt.Fprintln(tw, strings.Join(row(r), "\t"))
+		}
+		return tw.Flush()
+
+	default:
+		return validateOutput(format)
+	}
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/lsherman98/ytrss-cli/api"
+)
+
+func runPodcasts(ctx context.Context, args []string) error {
+	fs := newFlagSet("podcasts", "ytrss podcasts [--output format]")
+	output := outputFlag(fs)
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageError("unexpected arguments: %v", positional)
+	}
+
+	podcasts, err := api.ListPodcasts(ctx)
+	if err != nil {
+		return err
+	}
+
+	return render(os.Stdout, *output, []string{"ID", "TITLE"}, podcasts, func(p api.Podcast) []string {
+		return []string{p.ID, p.Title}
+	})
+}
+
+// resolvePodcast finds a podcast by exact ID, falling back to a
+// case-insensitive title match.
+func resolvePodcast(ctx context.Context, idOrTitle string) (api.Podcast, error) {
+	podcasts, err := api.ListPodcasts(ctx)
+	if err != nil {
+		return api.Podcast{}, err
+	}
+	return matchPodcast(podcasts, idOrTitle)
+}
+
+func matchPodcast(podcasts []api.Podcast, idOrTitle string) (api.Podcast, error) {
+	for _, p := range podcasts {
+		if p.ID == idOrTitle {
+			return p, nil
+		}
+	}
+
+	var matches []api.Podcast
+	for _, p := range podcasts {
+		if strings.EqualFold(strings.TrimSpace(p.Title), strings.TrimSpace(idOrTitle)) {
+			matches = append(matches, p)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return api.Podcast{}, fmt.Errorf("no podcast matches %q", idOrTitle)
+	case 1:
+		return matches[0], nil
+	default:
+		return api.Podcast{}, fmt.Errorf("%d podcasts are titled %q, use the podcast ID instead", len(matches), idOrTitle)
+	}
+}
@@ -0,0 +1,159 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/lsherman98/ytrss-cli/api"
+	"github.com/lsherman98/ytrss-cli/history"
+)
+
+var queueHeader = []string{"ID", "QUEUED", "PODCAST", "URL", "ATTEMPTS", "NEXT ATTEMPT", "LAST ERROR"}
+
+func runQueue(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usageError("usage: ytrss queue list|flush|remove")
+	}
+
+	switch args[0] {
+	case "list":
+		return runQueueList(args[1:])
+	case "flush":
+		return runQueueFlush(ctx, args[1:])
+	case "remove":
+		return runQueueRemove(args[1:])
+	}
+	return usageError("unknown queue command %q", args[0])
+}
+
+func runQueueList(args []string) error {
+	fs := newFlagSet("queue list", "ytrss queue list [--output format]")
+	output := outputFlag(fs)
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageError("unexpected arguments: %v", positional)
+	}
+	if err := validateOutput(*output); err != nil {
+		return err
+	}
+
+	store, err := history.OpenDefault()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	queue, err := store.Queue(current.profile)
+	if err != nil {
+		return err
+	}
+	return render(os.Stdout, *output, queueHeader, queue, func(q history.QueuedURL) []string {
+		return queueRow(q, *output == outputTable)
+	})
+}
+
+// runQueueFlush sends every queued URL of the profile now, whether or not
+// its next attempt is due.
+func runQueueFlush(ctx context.Context, args []string) error {
+	fs := newFlagSet("queue flush", "ytrss queue flush")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageError("unexpected arguments: %v", positional)
+	}
+
+	result, err := flushQueue(ctx, true)
+	printFlushed(os.Stdout, result)
+	if err != nil {
+		return err
+	}
+	if result.Remaining > 0 {
+		if result.Err != nil {
+			return fmt.Errorf("%d URLs are still queued: %w", result.Remaining, result.Err)
+		}
+		return fmt.Errorf("%d URLs are still queued", result.Remaining)
+	}
+	if len(result.Sent)+len(result.Rejected) == 0 {
+		fmt.Fprintln(os.Stderr, "No URLs queued")
+	}
+	if len(result.Rejected) > 0 {
+		return fmt.Errorf("%d of %d queued URLs could not be added", len(result.Rejected), len(result.Sent)+len(result.Rejected))
+	}
+	return nil
+}
+
+func runQueueRemove(args []string) error {
+	fs := newFlagSet("queue remove", "ytrss queue remove <id>")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usageError("expected exactly one queued URL ID")
+	}
+	id, err := strconv.ParseUint(positional[0], 10, 64)
+	if err != nil {
+		return usageError("invalid ID %q", positional[0])
+	}
+
+	store, err := history.OpenDefault()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if err := store.Remove(id); errors.Is(err, history.ErrNotQueued) {
+		return fmt.Errorf("%w, see ytrss queue list", err)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// flushQueue sends the queued URLs of the profile, only those due unless
+// force is set.
+func flushQueue(ctx context.Context, force bool) (history.FlushResult, error) {
+	path, err := history.DefaultPath()
+	if err != nil {
+		return history.FlushResult{}, err
+	}
+	return history.Flush(ctx, path, api.Default(), current.profile, force)
+}
+
+// printFlushed prints a line for every URL a flush sent or had rejected.
+func printFlushed(w io.Writer, result history.FlushResult) {
+	for _, r := range result.Sent {
+		fmt.Fprintf(w, "sent\t%s\t%s\t%s\n", r.Item.Status, firstNonEmpty(r.Item.Title, "-"), r.URL)
+	}
+	for _, r := range result.Rejected {
+		fmt.Fprintf(w, "rejected\t%s\t%s\n", r.URL, r.Error)
+	}
+}
+
+func queueRow(q history.QueuedURL, human bool) []string {
+	attempts := strconv.Itoa(q.Attempts)
+	if !human {
+		var next string
+		if !q.NextAttempt.IsZero() {
+			next = q.NextAttempt.Format(time.RFC3339)
+		}
+		return []string{strconv.FormatUint(q.ID, 10), q.Queued.Format(time.RFC3339), q.PodcastID, q.URL, attempts, next, q.LastError}
+	}
+
+	next := "now"
+	if !q.Due(time.Now()) {
+		next = q.NextAttempt.Local().Format("Jan 2, 2006 3:04 PM")
+	}
+	podcast := firstNonEmpty(q.PodcastTitle, q.PodcastID)
+	return []string{strconv.FormatUint(q.ID, 10), q.Queued.Local().Format("Jan 2, 2006 3:04 PM"), podcast, q.URL, attempts, next, firstNonEmpty(q.LastError, "-")}
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lsherman98/ytrss-cli/api"
+	"github.com/lsherman98/ytrss-cli/ui"
+	"github.com/lsherman98/ytrss-cli/updater"
+)
+
+func runTUI(s *session) int {
+	if err := ui.SetTheme(s.settings.Theme); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+
+	profile := ui.Profile{
+		Name:           s.profile,
+		Service:        api.Default(),
+		Credentials:    api.Default().Credentials(),
+		DefaultPodcast: s.defaultPodcast(),
+	}
+	names := s.file.ProfileNames()
+	if !slices.Contains(names, s.profile) {
+		names = append(names, s.profile)
+	}
+
+	model := ui.InitialModel(profile, names, s.loadProfile)
+	model.PollInterval = s.settings.PollInterval
+	if mode := s.updateMode(); mode != updater.ModeOff {
+		model.CheckUpdate = func(ctx context.Context) (string, error) {
+			u, err := s.updater()
+			if err != nil {
+				return "", err
+			}
+			return u.Check(ctx, s.build.Version, mode)
+		}
+	}
+
+	p := tea.NewProgram(model, tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		fmt.Printf("Uh oh, there was an error: %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// updateMode returns the configured update mode, or ModeOff when
+// $YTRSS_NO_UPDATE is set.
+func (s *session) updateMode() updater.Mode {
+	if os.Getenv("YTRSS_NO_UPDATE") != "" {
+		return updater.ModeOff
+	}
+	return updater.Mode(s.settings.Update.Mode)
+}
+
+// updater returns an Updater that verifies releases with the configured
+// public key, if any.
+func (s *session) updater() (*updater.Updater, error) {
+	update := s.settings.Update
+	source, err := updater.NewSource(updater.SourceConfig{Kind: update.Source, URL: update.URL})
+	if err != nil {
+		return nil, err
+	}
+	opts := updater.Options{
+		Source:     source,
+		Repository: update.Repository,
+		Channel:    updater.Channel(update.Channel),
+		Pin:        update.Pin,
+	}
+	if path := update.PublicKeyFile; path != "" {
+		key, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading update public key: %w", err)
+		}
+		opts.PublicKey = key
+	}
+	return updater.New(opts)
+}
+
+func (s *session) loadProfile(name string) (ui.Profile, error) {
+	settings, err := s.resolve(name)
+	if err != nil {
+		return ui.Profile{}, err
+	}
+	client, err := s.client(settings)
+	if err != nil {
+		return ui.Profile{}, err
+	}
+	return ui.Profile{
+		Name:           name,
+		Service:        client,
+		Credentials:    client.Credentials(),
+		DefaultPodcast: settings.DefaultPodcast,
+	}, nil
+}
@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	selfupdate "github.com/creativeprojects/go-selfupdate"
+	"github.com/lsherman98/ytrss-cli/updater"
+)
+
+func runUpdate(ctx context.Context, args []string) error {
+	fs := newFlagSet("update", "ytrss update [--check] [--to vX.Y.Z] [--rollback] [--yes]")
+	check := fs.Bool("check", false, "only report whether a newer version is available")
+	to := fs.String("to", "", "install this version instead of the latest, e.g. v1.2.3")
+	rollback := fs.Bool("rollback", false, "restore the version replaced by the last update")
+	yes := fs.Bool("yes", false, "install without asking for confirmation")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageError("unexpected arguments: %v", positional)
+	}
+	if *check && *to != "" {
+		return usageError("--check and --to cannot be used together")
+	}
+
+	currentVersion := current.build.Version
+	if *rollback {
+		if *check || *to != "" {
+			return usageError("--rollback cannot be used with --check or --to")
+		}
+		return runRollback(currentVersion, *yes)
+	}
+
+	install, err := updater.DetectInstall()
+	if err != nil {
+		return err
+	}
+	if !*check && !install.SelfUpdatable() {
+		return errors.New(install.Advice())
+	}
+	u, err := current.updater()
+	if err != nil {
+		return err
+	}
+
+	var release *selfupdate.Release
+	if *to != "" {
+		release, err = u.FindRelease(ctx, *to)
+		if err != nil {
+			return err
+		}
+	} else {
+		var outdated bool
+		release, outdated, err = u.CheckForUpdate(ctx, currentVersion)
+		if err != nil {
+			return err
+		}
+		if !outdated {
+			fmt.Printf("ytrss %s is up to date\n", currentVersion)
+			return nil
+		}
+		if *check {
+			fmt.Printf("Version %s is available (current: %s)\n", release.Version(), currentVersion)
+			if !install.SelfUpdatable() {
+				fmt.Println(install.Advice())
+			}
+			return nil
+		}
+	}
+
+	fmt.Printf("ytrss %s → %s\n", currentVersion, release.Version())
+	if notes := strings.TrimSpace(release.ReleaseNotes); notes != "" {
+		fmt.Printf("\n%s\n\n", notes)
+	}
+
+	if !*yes {
+		ok, err := confirmUpdate(fmt.Sprintf("Install version %s?", release.Version()))
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	if err := u.DoSelfUpdate(ctx, release, currentVersion); err != nil {
+		return err
+	}
+	fmt.Printf("Updated to version %s\n", release.Version())
+	return nil
+}
+
+func runRollback(currentVersion string, yes bool) error {
+	install, err := updater.DetectInstall()
+	if err != nil {
+		return err
+	}
+	if !install.SelfUpdatable() {
+		return errors.New(install.Advice())
+	}
+
+	previous := updater.LoadState().PreviousVersion
+	if !yes {
+		question := "Restore the previous version?"
+		if previous != "" {
+			question = fmt.Sprintf("Restore version %s?", previous)
+		}
+		ok, err := confirmUpdate(question)
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	restored, err := updater.Rollback(currentVersion)
+	if errors.Is(err, updater.ErrNoBackup) {
+		return fmt.Errorf("%w, ytrss keeps one after ytrss update installs a new version", err)
+	}
+	if err != nil {
+		return err
+	}
+	if restored == "" {
+		fmt.Println("Restored the previous version")
+	} else {
+		fmt.Printf("Restored version %s\n", restored)
+	}
+	fmt.Println("Run ytrss update --rollback again to undo")
+	return nil
+}
+
+// confirmUpdate asks for confirmation before the executable is replaced.
+// Without a terminal --yes is required.
+func confirmUpdate(question string) (bool, error) {
+	if !isTerminal(os.Stdin) {
+		return false, usageError("pass --yes to update without a terminal")
+	}
+	return confirm(question)
+}
+
+// confirm asks a yes/no question on stderr, defaulting to no.
+func confirm(question string) (bool, error) {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && answer == "" {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
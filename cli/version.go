@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+)
+
+type versionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+func runVersion(_ context.Context, args []string) error {
+	fs := newFlagSet("version", "ytrss version [--json]")
+	asJSON := fs.Bool("json", false, "print the build information as JSON")
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 0 {
+		return usageError("unexpected arguments: %v", positional)
+	}
+
+	info := buildVersionInfo(current.build)
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+
+	fmt.Printf("ytrss %s\n", info.Version)
+	fmt.Printf("commit:   %s\n", info.Commit)
+	fmt.Printf("built:    %s\n", info.Date)
+	fmt.Printf("go:       %s\n", info.GoVersion)
+	fmt.Printf("platform: %s\n", info.Platform)
+	return nil
+}
+
+// buildVersionInfo describes the running binary. Builds made without the
+// release ldflags fall back to the VCS information recorded by the Go
+// toolchain.
+func buildVersionInfo(build BuildInfo) versionInfo {
+	info := versionInfo{
+		Version:   build.Version,
+		Commit:    build.Commit,
+		Date:      build.Date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && (info.Commit == "" || info.Commit == "none"):
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && (info.Date == "" || info.Date == "unknown"):
+				info.Date = s.Value
+			}
+		}
+	}
+	return info
+}
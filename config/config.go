@@ -0,0 +1,182 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lsherman98/ytrss-cli/api"
+	"github.com/lsherman98/ytrss-cli/xdg"
+	"gopkg.in/yaml.v3"
+)
+
+const fileName = "config.yaml"
+
+type Config struct {
+	// Profile is the profile used when neither --profile nor $YTRSS_PROFILE
+	// is set.
+	Profile           string `yaml:"profile,omitempty"`
+	APIURL            string `yaml:"api_url,omitempty"`
+	AllowInsecureHTTP bool   `yaml:"allow_insecure_http,omitempty"`
+	// APIKeyFile and APIKeyCommand are alternative sources of the API key,
+	// tried before the encrypted credentials file and the OS keyring.
+	APIKeyFile     string        `yaml:"api_key_file,omitempty"`
+	APIKeyCommand  string        `yaml:"api_key_command,omitempty"`
+	DefaultPodcast string        `yaml:"default_podcast,omitempty"`
+	PollInterval   time.Duration `yaml:"poll_interval,omitempty"`
+	RequestTimeout time.Duration `yaml:"request_timeout,omitempty"`
+	// Retries is a pointer so that an explicit 0 can be told apart from
+	// an unset value.
+	Retries *int   `yaml:"retries,omitempty"`
+	Theme   string `yaml:"theme,omitempty"`
+	Update  Update `yaml:"update,omitempty"`
+
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
+}
+
+type Update struct {
+	// Mode is one of UpdateModes.
+	Mode string `yaml:"mode,omitempty"`
+	// PublicKeyFile is a PEM-encoded ECDSA key that checksums.txt of every
+	// release must be signed with.
+	PublicKeyFile string `yaml:"public_key_file,omitempty"`
+	// Channel is one of UpdateChannels.
+	Channel string `yaml:"channel,omitempty"`
+	// Pin is a semver constraint, such as ~1.4, that updates must satisfy.
+	Pin string `yaml:"pin,omitempty"`
+	// Source is one of UpdateSources. URL and Repository locate the
+	// releases on it.
+	Source     string `yaml:"source,omitempty"`
+	URL        string `yaml:"url,omitempty"`
+	Repository string `yaml:"repository,omitempty"`
+}
+
+// Profile holds the settings of a named account. Empty fields fall back to
+// the top-level settings.
+type Profile struct {
+	APIURL         string `yaml:"api_url,omitempty"`
+	DefaultPodcast string `yaml:"default_podcast,omitempty"`
+	APIKeyFile     string `yaml:"api_key_file,omitempty"`
+	APIKeyCommand  string `yaml:"api_key_command,omitempty"`
+}
+
+var unknownFieldPattern = regexp.MustCompile(`field (\S+) not found in type \S+`)
+
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// ValidateProfileName reports whether name can be used as a profile name.
+// Names end up in keyring entries and file names, so only letters, digits,
+// '.', '_' and '-' are allowed.
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	return nil
+}
+
+// ProfileNames returns the default profile and every profile in the config
+// file, sorted.
+func (c *Config) ProfileNames() []string {
+	names := []string{api.DefaultProfile}
+	for name := range c.Profiles {
+		if name != api.DefaultProfile {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Path returns the location of the config file, $YTRSS_CONFIG if set or
+// config.yaml in the XDG config directory.
+func Path() (string, error) {
+	if path := os.Getenv("YTRSS_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := xdg.ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fileName), nil
+}
+
+// Load reads and validates the config file. A missing file yields an empty
+// config.
+func Load() (*Config, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Parse decodes and validates a config file. Unknown keys are errors so
+// that typos do not go unnoticed.
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		msg := unknownFieldPattern.ReplaceAllString(err.Error(), `unknown key "$1"`)
+		if msg != err.Error() {
+			return nil, fmt.Errorf("%s\nknown keys: %s, profiles.<name>.{%s}", msg, strings.Join(Keys(), ", "), strings.Join(ProfileKeys(), ","))
+		}
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Save writes cfg to the config file, replacing it atomically.
+func Save(cfg *Config) error {
+	path, err := Path()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(cfg); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+fileName+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,502 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/lsherman98/ytrss-cli/api"
+)
+
+// Themes are the accepted values of the theme key.
+var Themes = []string{"default", "mono"}
+
+// UpdateModes are the accepted values of the update.mode key: install new
+// releases, only report them, or do not check at all.
+var UpdateModes = []string{"auto", "notify", "off"}
+
+// UpdateChannels are the accepted values of the update.channel key: only
+// regular releases, or prereleases too.
+var UpdateChannels = []string{"stable", "beta"}
+
+// UpdateSources are the accepted values of the update.source key.
+var UpdateSources = []string{"github", "gitlab", "gitea", "http"}
+
+// setting describes a top-level key: how it is read from and stored in a
+// Config, and its default. set validates value and clears the key when it
+// is empty.
+type setting struct {
+	name  string
+	usage string
+	def   string
+	get   func(c *Config) string
+	set   func(c *Config, value string) error
+}
+
+// profileSetting describes a key that a profile can override.
+type profileSetting struct {
+	name string
+	get  func(p *Profile) string
+	set  func(p *Profile, value string) error
+}
+
+var settings = []setting{
+	{
+		name:  "profile",
+		usage: "profile used when none is selected",
+		def:   api.DefaultProfile,
+		get:   func(c *Config) string { return c.Profile },
+		set: func(c *Config, v string) error {
+			if v != "" {
+				if err := ValidateProfileName(v); err != nil {
+					return err
+				}
+			}
+			c.Profile = v
+			return nil
+		},
+	},
+	{
+		name:  "api_url",
+		usage: "base URL of the API",
+		def:   api.BaseURL,
+		get:   func(c *Config) string { return c.APIURL },
+		set:   stringSetter(validateURL, func(c *Config) *string { return &c.APIURL }),
+	},
+	{
+		name:  "allow_insecure_http",
+		usage: "allow sending the API key over plain HTTP to non-loopback hosts",
+		def:   "false",
+		get: func(c *Config) string {
+			if !c.AllowInsecureHTTP {
+				return ""
+			}
+			return "true"
+		},
+		set: func(c *Config, v string) error {
+			if v == "" {
+				c.AllowInsecureHTTP = false
+				return nil
+			}
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return fmt.Errorf("%q is not a boolean, use true or false", v)
+			}
+			c.AllowInsecureHTTP = b
+			return nil
+		},
+	},
+	{
+		name:  "api_key_file",
+		usage: "file to read the API key from",
+		get:   func(c *Config) string { return c.APIKeyFile },
+		set:   stringSetter(nil, func(c *Config) *string { return &c.APIKeyFile }),
+	},
+	{
+		name:  "api_key_command",
+		usage: "shell command that prints the API key",
+		get:   func(c *Config) string { return c.APIKeyCommand },
+		set:   stringSetter(nil, func(c *Config) *string { return &c.APIKeyCommand }),
+	},
+	{
+		name:  "default_podcast",
+		usage: "podcast ID or title used when none is given",
+		get:   func(c *Config) string { return c.DefaultPodcast },
+		set:   stringSetter(nil, func(c *Config) *string { return &c.DefaultPodcast }),
+	},
+	{
+		name:  "poll_interval",
+		usage: "how often item status is refreshed while waiting",
+		def:   api.PollInterval.String(),
+		get:   func(c *Config) string { return durationString(c.PollInterval) },
+		set:   durationSetter(time.Second, func(c *Config) *time.Duration { return &c.PollInterval }),
+	},
+	{
+		name:  "request_timeout",
+		usage: "maximum duration of a single API request",
+		def:   api.DefaultRequestTimeout.String(),
+		get:   func(c *Config) string { return durationString(c.RequestTimeout) },
+		set:   durationSetter(0, func(c *Config) *time.Duration { return &c.RequestTimeout }),
+	},
+	{
+		name:  "retries",
+		usage: "number of times a failed API request is retried",
+		def:   strconv.Itoa(api.DefaultRetryPolicy.MaxAttempts - 1),
+		get: func(c *Config) string {
+			if c.Retries == nil {
+				return ""
+			}
+			return strconv.Itoa(*c.Retries)
+		},
+		set: func(c *Config, v string) error {
+			if v == "" {
+				c.Retries = nil
+				return nil
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fmt.Errorf("%q is not a valid number of retries, use 0 or more", v)
+			}
+			c.Retries = &n
+			return nil
+		},
+	},
+	{
+		name:  "theme",
+		usage: "colour theme of the interactive interface",
+		def:   Themes[0],
+		get:   func(c *Config) string { return c.Theme },
+		set:   stringSetter(oneOf("theme", Themes), func(c *Config) *string { return &c.Theme }),
+	},
+	{
+		name:  "update.mode",
+		usage: "what to do about new releases: auto installs them, notify only reports them, off disables the check",
+		def:   UpdateModes[0],
+		get:   func(c *Config) string { return c.Update.Mode },
+		set:   stringSetter(oneOf("update mode", UpdateModes), func(c *Config) *string { return &c.Update.Mode }),
+	},
+	{
+		name:  "update.public_key_file",
+		usage: "PEM-encoded ECDSA public key that release checksums must be signed with",
+		get:   func(c *Config) string { return c.Update.PublicKeyFile },
+		set:   stringSetter(nil, func(c *Config) *string { return &c.Update.PublicKeyFile }),
+	},
+	{
+		name:  "update.channel",
+		usage: "releases to follow: stable, or beta to include prereleases",
+		def:   UpdateChannels[0],
+		get:   func(c *Config) string { return c.Update.Channel },
+		set:   stringSetter(oneOf("update channel", UpdateChannels), func(c *Config) *string { return &c.Update.Channel }),
+	},
+	{
+		name:  "update.pin",
+		usage: "semver constraint that updates must satisfy, e.g. ~1.4",
+		get:   func(c *Config) string { return c.Update.Pin },
+		set:   stringSetter(validateConstraint, func(c *Config) *string { return &c.Update.Pin }),
+	},
+	{
+		name:  "update.source",
+		usage: "where releases are published: github, gitlab, gitea, or http for a static manifest.yaml",
+		def:   UpdateSources[0],
+		get:   func(c *Config) string { return c.Update.Source },
+		set:   stringSetter(oneOf("update source", UpdateSources), func(c *Config) *string { return &c.Update.Source }),
+	},
+	{
+		name:  "update.url",
+		usage: "base URL of the release server, required for gitea and http",
+		get:   func(c *Config) string { return c.Update.URL },
+		set:   stringSetter(validateURL, func(c *Config) *string { return &c.Update.URL }),
+	},
+	{
+		name:  "update.repository",
+		usage: "owner/name of the project on the release server",
+		def:   "lsherman98/ytrss-cli",
+		get:   func(c *Config) string { return c.Update.Repository },
+		set:   stringSetter(validateRepository, func(c *Config) *string { return &c.Update.Repository }),
+	},
+}
+
+var profileSettings = []profileSetting{
+	{
+		name: "api_url",
+		get:  func(p *Profile) string { return p.APIURL },
+		set:  profileSetter(validateURL, func(p *Profile) *string { return &p.APIURL }),
+	},
+	{
+		name: "default_podcast",
+		get:  func(p *Profile) string { return p.DefaultPodcast },
+		set:  profileSetter(nil, func(p *Profile) *string { return &p.DefaultPodcast }),
+	},
+	{
+		name: "api_key_file",
+		get:  func(p *Profile) string { return p.APIKeyFile },
+		set:  profileSetter(nil, func(p *Profile) *string { return &p.APIKeyFile }),
+	},
+	{
+		name: "api_key_command",
+		get:  func(p *Profile) string { return p.APIKeyCommand },
+		set:  profileSetter(nil, func(p *Profile) *string { return &p.APIKeyCommand }),
+	},
+}
+
+func stringSetter(validate func(string) error, field func(*Config) *string) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		if v != "" && validate != nil {
+			if err := validate(v); err != nil {
+				return err
+			}
+		}
+		*field(c) = v
+		return nil
+	}
+}
+
+func profileSetter(validate func(string) error, field func(*Profile) *string) func(*Profile, string) error {
+	return func(p *Profile, v string) error {
+		if v != "" && validate != nil {
+			if err := validate(v); err != nil {
+				return err
+			}
+		}
+		*field(p) = v
+		return nil
+	}
+}
+
+func durationSetter(minimum time.Duration, field func(*Config) *time.Duration) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		if v == "" {
+			*field(c) = 0
+			return nil
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration, use a value such as 30s or 2m", v)
+		}
+		if d < minimum {
+			return fmt.Errorf("%s is too short, use at least %s", d, minimum)
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+func oneOf(what string, values []string) func(string) error {
+	return func(v string) error {
+		if !slices.Contains(values, v) {
+			return fmt.Errorf("unknown %s %q, use one of %s", what, v, strings.Join(values, ", "))
+		}
+		return nil
+	}
+}
+
+func durationString(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
+
+func validateURL(v string) error {
+	u, err := url.Parse(v)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an http or https URL", v)
+	}
+	return nil
+}
+
+func validateConstraint(v string) error {
+	if _, err := semver.NewConstraint(v); err != nil {
+		return fmt.Errorf("%q is not a version constraint, use a value such as ~1.4 or <2", v)
+	}
+	return nil
+}
+
+func validateRepository(v string) error {
+	owner, name, ok := strings.Cut(v, "/")
+	if !ok || owner == "" || name == "" {
+		return fmt.Errorf("%q is not a repository, use owner/name", v)
+	}
+	return nil
+}
+
+// Keys returns the names of the top-level keys.
+func Keys() []string {
+	names := make([]string, len(settings))
+	for i, s := range settings {
+		names[i] = s.name
+	}
+	return names
+}
+
+// ProfileKeys returns the names of the keys a profile can override.
+func ProfileKeys() []string {
+	names := make([]string, len(profileSettings))
+	for i, s := range profileSettings {
+		names[i] = s.name
+	}
+	return names
+}
+
+// Usage describes the top-level key name.
+func Usage(name string) string {
+	if s, ok := lookupSetting(name); ok {
+		return s.usage
+	}
+	return ""
+}
+
+// EnvVar returns the environment variable that overrides key, e.g.
+// YTRSS_API_URL for api_url or YTRSS_UPDATE_MODE for update.mode.
+func EnvVar(key string) string {
+	return "YTRSS_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+func flagName(key string) string {
+	return strings.ReplaceAll(key, "_", "-")
+}
+
+func lookupSetting(name string) (setting, bool) {
+	for _, s := range settings {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+func lookupProfileSetting(name string) (profileSetting, bool) {
+	for _, s := range profileSettings {
+		if s.name == name {
+			return s, true
+		}
+	}
+	return profileSetting{}, false
+}
+
+// splitProfileKey splits profiles.<name>.<key> into the profile name and
+// key.
+func splitProfileKey(key string) (profile, name string, ok bool) {
+	rest, ok := strings.CutPrefix(key, "profiles.")
+	if !ok {
+		return "", "", false
+	}
+	i := strings.LastIndex(rest, ".")
+	if i <= 0 {
+		return "", "", false
+	}
+	return rest[:i], rest[i+1:], true
+}
+
+func unknownKey(key string) error {
+	return fmt.Errorf("unknown key %q, use one of %s or profiles.<name>.{%s}", key, strings.Join(Keys(), ", "), strings.Join(ProfileKeys(), ","))
+}
+
+// Get returns the value of key as stored in the config file, or "" if it
+// is not set. Keys of profiles are written profiles.<name>.<key>.
+func (c *Config) Get(key string) (string, error) {
+	if profile, name, ok := splitProfileKey(key); ok {
+		s, ok := lookupProfileSetting(name)
+		if !ok {
+			return "", unknownKey(key)
+		}
+		p := c.Profiles[profile]
+		return s.get(&p), nil
+	}
+
+	s, ok := lookupSetting(key)
+	if !ok {
+		return "", unknownKey(key)
+	}
+	return s.get(c), nil
+}
+
+// Set validates value and stores it under key. An empty value removes the
+// key.
+func (c *Config) Set(key, value string) error {
+	if profile, name, ok := splitProfileKey(key); ok {
+		s, ok := lookupProfileSetting(name)
+		if !ok {
+			return unknownKey(key)
+		}
+		if err := ValidateProfileName(profile); err != nil {
+			return err
+		}
+		p := c.Profiles[profile]
+		if err := s.set(&p, value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if c.Profiles == nil {
+			c.Profiles = map[string]Profile{}
+		}
+		c.Profiles[profile] = p
+		return nil
+	}
+
+	s, ok := lookupSetting(key)
+	if !ok {
+		return unknownKey(key)
+	}
+	if err := s.set(c, value); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return nil
+}
+
+// Validate checks every value in the config, reporting all problems at
+// once.
+func (c *Config) Validate() error {
+	var errs []error
+	for _, s := range settings {
+		if v := s.get(c); v != "" {
+			if err := s.set(&Config{}, v); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Profiles)) {
+		if err := ValidateProfileName(name); err != nil {
+			errs = append(errs, fmt.Errorf("profiles: %w", err))
+			continue
+		}
+		p := c.Profiles[name]
+		for _, s := range profileSettings {
+			if v := s.get(&p); v != "" {
+				if err := s.set(&Profile{}, v); err != nil {
+					errs = append(errs, fmt.Errorf("profiles.%s.%s: %w", name, s.name, err))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Lookup returns the effective value of a top-level key and where it came
+// from. Values are taken from flags, the environment, the selected
+// profile, the config file or the default, in that order. flags maps flag
+// names, such as api-url, to the values given on the command line.
+func (c *Config) Lookup(key string, flags map[string]string) (value, source string) {
+	s, ok := lookupSetting(key)
+	if !ok {
+		return "", ""
+	}
+
+	if v, ok := flags[flagName(key)]; ok {
+		return v, "flag --" + flagName(key)
+	}
+	if v := os.Getenv(EnvVar(key)); v != "" {
+		return v, "env " + EnvVar(key)
+	}
+	if ps, ok := lookupProfileSetting(key); ok {
+		profile, _ := c.Lookup("profile", flags)
+		p := c.Profiles[profile]
+		if v := ps.get(&p); v != "" {
+			return v, "profile " + profile
+		}
+	}
+	if v := s.get(c); v != "" {
+		return v, "file"
+	}
+	return s.def, "default"
+}
+
+// Resolve returns the effective configuration for the given flags, with
+// every key set as described by Lookup. Invalid values given through flags
+// or the environment are reported with their source.
+func (c *Config) Resolve(flags map[string]string) (*Config, error) {
+	resolved := &Config{}
+	for _, s := range settings {
+		v, source := c.Lookup(s.name, flags)
+		if err := s.set(resolved, v); err != nil {
+			return nil, fmt.Errorf("%s (from %s): %w", s.name, source, err)
+		}
+	}
+	return resolved, nil
+}
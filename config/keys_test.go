@@ -0,0 +1,219 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lsherman98/ytrss-cli/api"
+)
+
+// clearEnv unsets the environment overrides of keys for the test.
+func clearEnv(t *testing.T, keys ...string) {
+	t.Helper()
+	for _, key := range keys {
+		t.Setenv(EnvVar(key), "")
+	}
+}
+
+func TestLookupPrecedence(t *testing.T) {
+	file := &Config{
+		APIURL: "https://file.example",
+		Profiles: map[string]Profile{
+			"work": {APIURL: "https://work.example"},
+		},
+	}
+	withProfile := &Config{APIURL: file.APIURL, Profile: "work", Profiles: file.Profiles}
+
+	tests := []struct {
+		name       string
+		cfg        *Config
+		env        string
+		flag       string
+		want       string
+		wantSource string
+	}{
+		{"default", &Config{}, "", "", api.BaseURL, "default"},
+		{"file over default", file, "", "", "https://file.example", "file"},
+		{"profile over file", withProfile, "", "", "https://work.example", "profile work"},
+		{"env over profile", withProfile, "https://env.example", "", "https://env.example", "env YTRSS_API_URL"},
+		{"flag over env", withProfile, "https://env.example", "https://flag.example", "https://flag.example", "flag --api-url"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t, "profile")
+			t.Setenv("YTRSS_API_URL", tt.env)
+			flags := map[string]string{}
+			if tt.flag != "" {
+				flags["api-url"] = tt.flag
+			}
+
+			value, source := tt.cfg.Lookup("api_url", flags)
+			if value != tt.want || source != tt.wantSource {
+				t.Errorf("Lookup = %q from %s, want %q from %s", value, source, tt.want, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestLookupFollowsSelectedProfile(t *testing.T) {
+	cfg := &Config{
+		Profile:        "work",
+		DefaultPodcast: "Top level",
+		Profiles: map[string]Profile{
+			"work":     {DefaultPodcast: "Work"},
+			"personal": {DefaultPodcast: "Personal"},
+			"other":    {DefaultPodcast: "Other"},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		env   string
+		flags map[string]string
+		want  string
+	}{
+		{"profile key of the file", "", nil, "Work"},
+		{"YTRSS_PROFILE", "personal", nil, "Personal"},
+		{"--profile over YTRSS_PROFILE", "personal", map[string]string{"profile": "other"}, "Other"},
+		{"profile without the key", "", map[string]string{"profile": "missing"}, "Top level"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t, "default_podcast")
+			t.Setenv("YTRSS_PROFILE", tt.env)
+
+			if value, _ := cfg.Lookup("default_podcast", tt.flags); value != tt.want {
+				t.Errorf("default_podcast = %q, want %q", value, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	clearEnv(t, Keys()...)
+	t.Setenv("YTRSS_RETRIES", "0")
+	cfg := &Config{PollInterval: 10 * time.Second, Theme: "mono"}
+
+	resolved, err := cfg.Resolve(map[string]string{"request-timeout": "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.PollInterval != 10*time.Second || resolved.Theme != "mono" || resolved.RequestTimeout != time.Minute {
+		t.Errorf("resolved = %+v", resolved)
+	}
+	if resolved.Retries == nil || *resolved.Retries != 0 {
+		t.Errorf("retries = %v, want an explicit 0 from the environment", resolved.Retries)
+	}
+	if resolved.Profile != api.DefaultProfile || resolved.Update.Mode != "auto" {
+		t.Errorf("resolved = %+v, want the defaults filled in", resolved)
+	}
+}
+
+func TestResolveNamesSourceOfInvalidValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   *Config
+		env   map[string]string
+		flags map[string]string
+		want  string
+	}{
+		{
+			name:  "flag",
+			cfg:   &Config{},
+			flags: map[string]string{"poll-interval": "soon"},
+			want:  `poll_interval (from flag --poll-interval): "soon" is not a duration`,
+		},
+		{
+			name: "env",
+			cfg:  &Config{},
+			env:  map[string]string{"YTRSS_UPDATE_MODE": "sometimes"},
+			want: `update.mode (from env YTRSS_UPDATE_MODE): unknown update mode "sometimes"`,
+		},
+		{
+			name: "profile",
+			cfg:  &Config{Profiles: map[string]Profile{"work": {APIURL: "ftp://example.com"}}},
+			env:  map[string]string{"YTRSS_PROFILE": "work"},
+			want: `api_url (from profile work): "ftp://example.com" is not an http or https URL`,
+		},
+		{
+			name: "file",
+			cfg:  &Config{Theme: "neon"},
+			want: `theme (from file): unknown theme "neon"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t, Keys()...)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			_, err := tt.cfg.Resolve(tt.flags)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Resolve error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"poll interval too short", Config{PollInterval: 100 * time.Millisecond}, "poll_interval: 100ms is too short"},
+		{"theme", Config{Theme: "neon"}, `theme: unknown theme "neon", use one of default, mono`},
+		{"update mode", Config{Update: Update{Mode: "sometimes"}}, `update.mode: unknown update mode "sometimes", use one of auto, notify, off`},
+		{"update channel", Config{Update: Update{Channel: "nightly"}}, `update.channel: unknown update channel "nightly"`},
+		{"update pin", Config{Update: Update{Pin: "latest"}}, `update.pin: "latest" is not a version constraint`},
+		{"profile name", Config{Profiles: map[string]Profile{"my profile": {}}}, `profiles: invalid profile name "my profile"`},
+		{"profile key", Config{Profiles: map[string]Profile{"work": {APIURL: "example.com"}}}, `profiles.work.api_url: "example.com" is not an http or https URL`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	valid := Config{PollInterval: 5 * time.Second, Theme: "mono", Update: Update{Mode: "notify", Pin: "~1.4"}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate of a valid config = %v", err)
+	}
+
+	// Every problem is reported at once.
+	err := (&Config{Theme: "neon", Update: Update{Mode: "sometimes"}}).Validate()
+	if err == nil || !strings.Contains(err.Error(), "theme") || !strings.Contains(err.Error(), "update.mode") {
+		t.Errorf("Validate = %v, want both problems", err)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"bad duration", "poll_interval: soon\n", "soon"},
+		{"unknown key", "them: mono\n", `unknown key "them"`},
+		{"invalid value", "update:\n  mode: sometimes\n", "update.mode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.data)); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse = %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	cfg, err := Parse([]byte("poll_interval: 10s\nprofiles:\n  work:\n    default_podcast: Daily\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.PollInterval != 10*time.Second || cfg.Profiles["work"].DefaultPodcast != "Daily" {
+		t.Errorf("Parse = %+v", cfg)
+	}
+}
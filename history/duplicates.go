@@ -0,0 +1,91 @@
+package history
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lsherman98/ytrss-cli/api"
+)
+
+// Duplicate reports why a video is already in a podcast, or "" if it is
+// not. records are the earlier submissions of the video to the podcast and
+// items the podcast's current items, nil when they could not be loaded. A
+// submission only counts while its item is still in the podcast and did
+// not fail; submissions whose item is unknown always count, unless the
+// request itself failed. title is compared against item titles when known.
+func Duplicate(records []Record, items []api.Item, title string) string {
+	for i := len(records) - 1; i >= 0; i-- {
+		r := records[i]
+		if r.Error != "" {
+			// The server refused the submission, so nothing was added.
+			continue
+		}
+		submitted := r.Submitted.Local().Format("Jan 2, 2006 3:04 PM")
+		if r.Item.Created == "" || items == nil {
+			return fmt.Sprintf("already submitted on %s", submitted)
+		}
+		for _, item := range items {
+			if item.Created == r.Item.Created && item.Status != api.StatusError {
+				return fmt.Sprintf("already submitted on %s", submitted)
+			}
+		}
+	}
+
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return ""
+	}
+	for _, item := range items {
+		if item.Status != api.StatusError && strings.EqualFold(strings.TrimSpace(item.Title), title) {
+			return fmt.Sprintf("an item titled %q already exists", item.Title)
+		}
+	}
+	return ""
+}
+
+type Candidate struct {
+	VideoID string
+	Title   string
+}
+
+// CheckAll returns, for each candidate, the reason it is a duplicate or ""
+// if it is not. Candidates repeating an earlier video ID or waiting in the
+// queue are duplicates too. store may be nil to only check items.
+func CheckAll(store *Store, podcastID string, items []api.Item, candidates []Candidate) ([]string, error) {
+	queued := make(map[string]QueuedURL)
+	if store != nil {
+		queue, err := store.Queue("")
+		if err != nil {
+			return nil, err
+		}
+		for _, q := range queue {
+			if q.PodcastID == podcastID {
+				queued[q.VideoID] = q
+			}
+		}
+	}
+
+	reasons := make([]string, len(candidates))
+	seen := make(map[string]bool)
+	for i, c := range candidates {
+		if seen[c.VideoID] {
+			reasons[i] = "listed more than once"
+			continue
+		}
+		seen[c.VideoID] = true
+		if q, ok := queued[c.VideoID]; ok {
+			reasons[i] = fmt.Sprintf("already queued on %s", q.Queued.Local().Format("Jan 2, 2006 3:04 PM"))
+			continue
+		}
+
+		var records []Record
+		if store != nil {
+			var err error
+			if records, err = store.Find(podcastID, c.VideoID); err != nil {
+				return nil, err
+			}
+		}
+		reasons[i] = Duplicate(records, items, c.Title)
+	}
+	return reasons, nil
+}
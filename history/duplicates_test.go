@@ -0,0 +1,133 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lsherman98/ytrss-cli/api"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := Open(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestFailedSubmissionDoesNotBlockResubmission(t *testing.T) {
+	store := openTestStore(t)
+	failed := Record{
+		URL:       "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+		VideoID:   "dQw4w9WgXcQ",
+		PodcastID: "p1",
+		Submitted: time.Now(),
+		Error:     "monthly quota exceeded",
+	}
+	if err := store.Add(failed); err != nil {
+		t.Fatal(err)
+	}
+
+	if reason := Duplicate([]Record{failed}, nil, ""); reason != "" {
+		t.Errorf("Duplicate = %q for a refused submission, want none", reason)
+	}
+	for _, items := range [][]api.Item{nil, {}} {
+		reasons, err := CheckAll(store, "p1", items, []Candidate{{VideoID: "dQw4w9WgXcQ"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reasons[0] != "" {
+			t.Errorf("CheckAll with items %v = %q, want the URL to be submitted again", items, reasons[0])
+		}
+	}
+}
+
+func TestDuplicate(t *testing.T) {
+	submitted := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	pending := Record{Submitted: submitted, Item: api.Item{Status: api.StatusCreated, Created: "2024-05-01 12:00:00"}}
+	unknown := Record{Submitted: submitted}
+	failed := Record{Submitted: submitted, Error: "quota exceeded"}
+	already := "already submitted on " + submitted.Local().Format("Jan 2, 2006 3:04 PM")
+
+	tests := []struct {
+		name    string
+		records []Record
+		items   []api.Item
+		title   string
+		want    string
+	}{
+		{"new video", nil, []api.Item{{Title: "Other", Status: api.StatusSuccess}}, "Talk", ""},
+		{"item still present", []Record{pending}, []api.Item{{Created: "2024-05-01 12:00:00", Status: api.StatusSuccess}}, "", already},
+		{"item deleted", []Record{pending}, []api.Item{{Created: "2024-05-02 08:00:00", Status: api.StatusSuccess}}, "", ""},
+		{"item failed to process", []Record{pending}, []api.Item{{Created: "2024-05-01 12:00:00", Status: api.StatusError}}, "", ""},
+		{"items unknown", []Record{pending}, nil, "", already},
+		{"item of record unknown", []Record{unknown}, []api.Item{}, "", already},
+		{"request failed", []Record{failed}, nil, "", ""},
+		{"title matches ignoring case", nil, []api.Item{{Title: "Opening Keynote", Status: api.StatusSuccess}}, "  opening KEYNOTE ", `an item titled "Opening Keynote" already exists`},
+		{"title of failed item", nil, []api.Item{{Title: "Opening Keynote", Status: api.StatusError}}, "Opening Keynote", ""},
+		{"no title", nil, []api.Item{{Title: "", Status: api.StatusSuccess}}, " ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Duplicate(tt.records, tt.items, tt.title); got != tt.want {
+				t.Errorf("Duplicate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckAll(t *testing.T) {
+	store := openTestStore(t)
+	queued := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, q := range []QueuedURL{
+		{VideoID: "queuedvideo", PodcastID: "p1", Queued: queued},
+		{VideoID: "otherpodcst", PodcastID: "p2", Queued: queued},
+	} {
+		if _, err := store.Enqueue(q); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Add(Record{VideoID: "submitted01", PodcastID: "p1", Submitted: queued, Item: api.Item{Created: "2024-05-01 12:00:00"}}); err != nil {
+		t.Fatal(err)
+	}
+	items := []api.Item{{Created: "2024-05-01 12:00:00", Status: api.StatusSuccess, Title: "Keynote"}}
+
+	candidates := []Candidate{
+		{VideoID: "newvideo001"},
+		{VideoID: "newvideo001"},
+		{VideoID: "queuedvideo"},
+		{VideoID: "otherpodcst"},
+		{VideoID: "submitted01"},
+		{VideoID: "newvideo002", Title: "keynote"},
+	}
+	reasons, err := CheckAll(store, "p1", items, candidates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	on := queued.Local().Format("Jan 2, 2006 3:04 PM")
+	want := []string{
+		"",
+		"listed more than once",
+		"already queued on " + on,
+		"", // queued for another podcast
+		"already submitted on " + on,
+		`an item titled "Keynote" already exists`,
+	}
+	for i := range want {
+		if reasons[i] != want[i] {
+			t.Errorf("%s: reason = %q, want %q", candidates[i].VideoID, reasons[i], want[i])
+		}
+	}
+
+	// Without a history only the batch and the items are checked.
+	reasons, err = CheckAll(nil, "p1", items, candidates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reasons[2] != "" || reasons[4] != "" || reasons[1] == "" || reasons[5] == "" {
+		t.Errorf("reasons without a history = %q", reasons)
+	}
+}
@@ -0,0 +1,269 @@
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/lsherman98/ytrss-cli/api"
+	"github.com/lsherman98/ytrss-cli/xdg"
+	bolt "go.etcd.io/bbolt"
+)
+
+var submissionsBucket = []byte("submissions")
+
+// StatusFailed is the status of a submission that did not reach the
+// podcast because the request failed.
+const StatusFailed = "FAILED"
+
+// Statuses are the statuses a record can have: those of api.Item and
+// StatusFailed.
+var Statuses = []string{api.StatusCreated, api.StatusSuccess, api.StatusError, StatusFailed}
+
+// Record is a URL submitted to a podcast along with the item the server
+// returned for it. Item is updated with the latest status seen, so once
+// processing is over it holds the final status.
+type Record struct {
+	ID           uint64    `json:"-"`
+	URL          string    `json:"url"`
+	VideoID      string    `json:"video_id"`
+	PodcastID    string    `json:"podcast_id"`
+	PodcastTitle string    `json:"podcast_title,omitempty"`
+	Profile      string    `json:"profile,omitempty"`
+	Submitted    time.Time `json:"submitted"`
+	Item         api.Item  `json:"item"`
+	// Error is set when the request failed, in which case Item is empty.
+	Error string `json:"error,omitempty"`
+}
+
+// Status returns the item's status, or StatusFailed.
+func (r Record) Status() string {
+	if r.Error != "" {
+		return StatusFailed
+	}
+	return r.Item.Status
+}
+
+// Filter selects records. Zero fields match every record.
+type Filter struct {
+	// Profile matches records of the profile. Records written before
+	// profiles existed belong to api.DefaultProfile.
+	Profile string
+	// Podcast matches the podcast ID or, ignoring case, its title.
+	Podcast string
+	Since   time.Time
+	// Status is one of Statuses, compared ignoring case.
+	Status string
+}
+
+// profileOf returns the profile a record or queued URL belongs to.
+func profileOf(profile string) string {
+	if profile == "" {
+		return api.DefaultProfile
+	}
+	return profile
+}
+
+func (f Filter) match(r Record) bool {
+	switch {
+	case f.Profile != "" && f.Profile != profileOf(r.Profile):
+		return false
+	case f.Podcast != "" && f.Podcast != r.PodcastID && !strings.EqualFold(f.Podcast, r.PodcastTitle):
+		return false
+	case !f.Since.IsZero() && r.Submitted.Before(f.Since):
+		return false
+	case f.Status != "" && !strings.EqualFold(f.Status, r.Status()):
+		return false
+	}
+	return true
+}
+
+type Store struct {
+	db *bolt.DB
+}
+
+func DefaultPath() (string, error) {
+	dir, err := xdg.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "history.db"), nil
+}
+
+// Open opens the history database at path, creating it if needed. The
+// database is locked while open, so callers should close it promptly.
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 2 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("opening history %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(submissionsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db}, nil
+}
+
+func OpenDefault() (*Store, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	return Open(path)
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Add appends r, whose ID is ignored.
+func (s *Store) Add(r Record) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return add(tx, r)
+	})
+}
+
+func add(tx *bolt.Tx, r Record) error {
+	b := tx.Bucket(submissionsBucket)
+	seq, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return b.Put(itob(seq), data)
+}
+
+func itob(n uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, n)
+	return key
+}
+
+// Find returns the records of a video submitted to a podcast, oldest
+// first. Failed submissions are left out.
+func (s *Store) Find(podcastID, videoID string) ([]Record, error) {
+	var records []Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(submissionsBucket).ForEach(func(k, v []byte) error {
+			r, err := decode(k, v)
+			if err != nil {
+				return err
+			}
+			if r.PodcastID == podcastID && r.VideoID == videoID && r.Error == "" {
+				records = append(records, r)
+			}
+			return nil
+		})
+	})
+	return records, err
+}
+
+// Query returns the records matching f, newest first.
+func (s *Store) Query(f Filter) ([]Record, error) {
+	var records []Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(submissionsBucket).Cursor()
+		for k, v := c.Last(); k != nil; k, v = c.Prev() {
+			r, err := decode(k, v)
+			if err != nil {
+				return err
+			}
+			if f.match(r) {
+				records = append(records, r)
+			}
+		}
+		return nil
+	})
+	return records, err
+}
+
+// UpdateItems stores the latest state of a podcast's items in the records
+// they were submitted by.
+func (s *Store) UpdateItems(podcastID string, items []api.Item) error {
+	latest := make(map[string]api.Item, len(items))
+	for _, item := range items {
+		if item.Created != "" {
+			latest[item.Created] = item
+		}
+	}
+	if len(latest) == 0 {
+		return nil
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(submissionsBucket)
+		updates := map[string][]byte{}
+		err := b.ForEach(func(k, v []byte) error {
+			r, err := decode(k, v)
+			if err != nil {
+				return err
+			}
+			item, ok := latest[r.Item.Created]
+			if r.PodcastID != podcastID || r.Item.Created == "" || !ok || item == r.Item {
+				return nil
+			}
+			r.Item = item
+			data, err := json.Marshal(r)
+			if err != nil {
+				return err
+			}
+			updates[string(k)] = data
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		// Buckets cannot be modified while iterating over them.
+		for k, data := range updates {
+			if err := b.Put([]byte(k), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func decode(k, v []byte) (Record, error) {
+	var r Record
+	if err := json.Unmarshal(v, &r); err != nil {
+		return r, err
+	}
+	r.ID = binary.BigEndian.Uint64(k)
+	return r, nil
+}
+
+// Podcasts returns the podcasts profile submitted to, most recently used
+// first, for use when the API cannot be reached to list them.
+func (s *Store) Podcasts(profile string) ([]api.Podcast, error) {
+	records, err := s.Query(Filter{Profile: profile})
+	if err != nil {
+		return nil, err
+	}
+	var podcasts []api.Podcast
+	seen := make(map[string]bool)
+	for _, r := range records {
+		if !seen[r.PodcastID] {
+			seen[r.PodcastID] = true
+			podcasts = append(podcasts, api.Podcast{ID: r.PodcastID, Title: r.PodcastTitle})
+		}
+	}
+	return podcasts, nil
+}
@@ -0,0 +1,112 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lsherman98/ytrss-cli/api"
+)
+
+func TestUpdateItems(t *testing.T) {
+	store := openTestStore(t)
+	submitted := time.Now()
+	for _, r := range []Record{
+		{URL: "one", PodcastID: "p1", Submitted: submitted, Item: api.Item{Status: api.StatusCreated, Created: "2024-05-01 12:00:00"}},
+		{URL: "two", PodcastID: "p1", Submitted: submitted, Item: api.Item{Status: api.StatusCreated, Created: "2024-05-01 12:05:00"}},
+		{URL: "three", PodcastID: "p1", Submitted: submitted, Item: api.Item{Status: api.StatusCreated, Created: "2024-05-01 12:10:00"}},
+		// The same creation time in another podcast is another item.
+		{URL: "other", PodcastID: "p2", Submitted: submitted, Item: api.Item{Status: api.StatusCreated, Created: "2024-05-01 12:00:00"}},
+		{URL: "failed", PodcastID: "p1", Submitted: submitted, Error: "quota exceeded"},
+	} {
+		if err := store.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := store.UpdateItems("p1", []api.Item{
+		{Status: api.StatusSuccess, Title: "One", Created: "2024-05-01 12:00:00"},
+		{Status: api.StatusError, Error: "video is private", Created: "2024-05-01 12:05:00"},
+		{Status: api.StatusSuccess, Title: "Not submitted from here", Created: "2024-05-01 13:00:00"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := store.Query(Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"one":    api.StatusSuccess,
+		"two":    api.StatusError,
+		"three":  api.StatusCreated,
+		"other":  api.StatusCreated,
+		"failed": StatusFailed,
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(records), len(want))
+	}
+	for _, r := range records {
+		if r.Status() != want[r.URL] {
+			t.Errorf("%s: status = %s, want %s", r.URL, r.Status(), want[r.URL])
+		}
+	}
+	if r := records[len(records)-1]; r.URL != "one" || r.Item.Title != "One" {
+		t.Errorf("oldest record = %+v, want one with its final title", r)
+	}
+	if r := records[len(records)-2]; r.Item.Error != "video is private" {
+		t.Errorf("record two = %+v, want the item's error", r)
+	}
+}
+
+func TestQueryFilters(t *testing.T) {
+	store := openTestStore(t)
+	now := time.Now()
+	for _, r := range []Record{
+		{URL: "old", PodcastID: "p1", PodcastTitle: "Daily", Submitted: now.Add(-72 * time.Hour), Item: api.Item{Status: api.StatusSuccess}},
+		{URL: "recent", PodcastID: "p1", PodcastTitle: "Daily", Submitted: now.Add(-4 * time.Hour), Item: api.Item{Status: api.StatusError}},
+		{URL: "failed", PodcastID: "p2", PodcastTitle: "Weekly", Submitted: now.Add(-3 * time.Hour), Error: "quota exceeded"},
+		{URL: "weekly", PodcastID: "p2", PodcastTitle: "Weekly", Submitted: now.Add(-2 * time.Hour), Item: api.Item{Status: api.StatusCreated}},
+		{URL: "work", PodcastID: "p1", PodcastTitle: "Daily", Profile: "work", Submitted: now, Item: api.Item{Status: api.StatusSuccess}},
+	} {
+		if err := store.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"everything, newest first", Filter{}, []string{"work", "weekly", "failed", "recent", "old"}},
+		{"podcast ID", Filter{Podcast: "p2"}, []string{"weekly", "failed"}},
+		{"podcast title ignoring case", Filter{Podcast: "daily"}, []string{"work", "recent", "old"}},
+		{"since", Filter{Since: now.Add(-24 * time.Hour)}, []string{"work", "weekly", "failed", "recent"}},
+		{"status ignoring case", Filter{Status: "error"}, []string{"recent"}},
+		{"failed", Filter{Status: StatusFailed}, []string{"failed"}},
+		{"default profile includes records without one", Filter{Profile: api.DefaultProfile}, []string{"weekly", "failed", "recent", "old"}},
+		{"named profile", Filter{Profile: "work"}, []string{"work"}},
+		{"combined", Filter{Podcast: "Daily", Since: now.Add(-24 * time.Hour), Status: api.StatusSuccess}, []string{"work"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := store.Query(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range records {
+				got = append(got, r.URL)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Query = %q, want %q", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("Query = %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
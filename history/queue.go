@@ -0,0 +1,295 @@
+package history
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lsherman98/ytrss-cli/api"
+	bolt "go.etcd.io/bbolt"
+)
+
+var queueBucket = []byte("queue")
+
+const (
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = time.Hour
+
+	// claimTimeout is how long a flush may take to send a URL before
+	// other flushes consider it abandoned, e.g. because ytrss was killed.
+	claimTimeout = 10 * time.Minute
+)
+
+// ErrNotQueued is returned by Remove for an unknown ID.
+var ErrNotQueued = errors.New("no such queued URL")
+
+// QueuedURL is a submission that could not be sent because the API was
+// unreachable, kept until a flush sends it.
+type QueuedURL struct {
+	ID           uint64    `json:"id"`
+	URL          string    `json:"url"`
+	VideoID      string    `json:"video_id"`
+	PodcastID    string    `json:"podcast_id"`
+	PodcastTitle string    `json:"podcast_title,omitempty"`
+	Profile      string    `json:"profile,omitempty"`
+	Queued       time.Time `json:"queued"`
+	// Attempts counts the flushes that failed to send the URL. The next
+	// automatic attempt waits for NextAttempt, backing off exponentially.
+	Attempts    int       `json:"attempts"`
+	LastError   string    `json:"last_error,omitempty"`
+	NextAttempt time.Time `json:"next_attempt,omitzero"`
+	// Claimed is until when a flush is sending the URL. Other flushes
+	// leave it alone meanwhile, even when forced.
+	Claimed time.Time `json:"claimed,omitzero"`
+}
+
+// Due reports whether an automatic flush should try q at now.
+func (q QueuedURL) Due(now time.Time) bool {
+	return !now.Before(q.NextAttempt)
+}
+
+func retryDelay(attempts int) time.Duration {
+	d := retryBaseDelay << (attempts - 1)
+	if d <= 0 || d > retryMaxDelay {
+		return retryMaxDelay
+	}
+	return d
+}
+
+// Enqueue adds q to the queue and returns it with its ID set.
+func (s *Store) Enqueue(q QueuedURL) (QueuedURL, error) {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(queueBucket)
+		if err != nil {
+			return err
+		}
+		if q.ID, err = b.NextSequence(); err != nil {
+			return err
+		}
+		return putQueued(b, q)
+	})
+	return q, err
+}
+
+// Queue returns the queued URLs of profile, oldest first, or of every
+// profile when profile is empty.
+func (s *Store) Queue(profile string) ([]QueuedURL, error) {
+	var queue []QueuedURL
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(queueBucket)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var q QueuedURL
+			if err := json.Unmarshal(v, &q); err != nil {
+				return err
+			}
+			q.ID = binary.BigEndian.Uint64(k)
+			if profile == "" || profile == profileOf(q.Profile) {
+				queue = append(queue, q)
+			}
+			return nil
+		})
+	})
+	return queue, err
+}
+
+// Remove drops a URL from the queue without sending it.
+func (s *Store) Remove(id uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(queueBucket)
+		if b == nil || b.Get(itob(id)) == nil {
+			return fmt.Errorf("%w: %d", ErrNotQueued, id)
+		}
+		return b.Delete(itob(id))
+	})
+}
+
+// claim marks the queued URL id as being sent by the caller and returns
+// it, or false when it is no longer queued or another flush is sending it.
+// The claim ends with retry or complete, or after claimTimeout.
+func (s *Store) claim(id uint64, now time.Time) (QueuedURL, bool, error) {
+	var q QueuedURL
+	var claimed bool
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(queueBucket)
+		if b == nil {
+			return nil
+		}
+		v := b.Get(itob(id))
+		if v == nil {
+			return nil
+		}
+		if err := json.Unmarshal(v, &q); err != nil {
+			return err
+		}
+		q.ID = id
+		if now.Before(q.Claimed) {
+			return nil
+		}
+		q.Claimed = now.Add(claimTimeout)
+		claimed = true
+		return putQueued(b, q)
+	})
+	return q, claimed, err
+}
+
+// retry records a failed attempt to send q and releases its claim.
+func (s *Store) retry(q QueuedURL, err error, now time.Time) error {
+	q.Attempts++
+	q.LastError = err.Error()
+	q.NextAttempt = now.Add(retryDelay(q.Attempts))
+	return s.release(q)
+}
+
+// release stores q with its claim released, unless it was removed in the
+// meantime.
+func (s *Store) release(q QueuedURL) error {
+	q.Claimed = time.Time{}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(queueBucket)
+		if b == nil || b.Get(itob(q.ID)) == nil {
+			// Removed while the request was in flight.
+			return nil
+		}
+		return putQueued(b, q)
+	})
+}
+
+// complete moves q out of the queue and into the history as r.
+func (s *Store) complete(q QueuedURL, r Record) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if b := tx.Bucket(queueBucket); b != nil {
+			if err := b.Delete(itob(q.ID)); err != nil {
+				return err
+			}
+		}
+		return add(tx, r)
+	})
+}
+
+func putQueued(b *bolt.Bucket, q QueuedURL) error {
+	data, err := json.Marshal(q)
+	if err != nil {
+		return err
+	}
+	return b.Put(itob(q.ID), data)
+}
+
+// FlushResult is the outcome of Flush.
+type FlushResult struct {
+	// Sent are the submissions the server accepted and Rejected those it
+	// refused, both as recorded in the history.
+	Sent     []Record
+	Rejected []Record
+	// Remaining counts the URLs still queued, and Err is why the last
+	// attempt failed when the API is still unreachable.
+	Remaining int
+	Err       error
+}
+
+// Flush sends the queued URLs of profile through svc, oldest first. Unless
+// force is set only URLs that are Due are tried. Flushing stops at the
+// first URL that fails because the API is unreachable, which stays queued
+// with its retry state updated; URLs the server refuses leave the queue
+// and are recorded as failed.
+//
+// The database at path is only opened between requests, so that
+// submissions can be queued while a flush is in progress.
+func Flush(ctx context.Context, path string, svc api.Service, profile string, force bool) (FlushResult, error) {
+	var result FlushResult
+	var queue []QueuedURL
+	err := withStore(path, func(s *Store) error {
+		var err error
+		queue, err = s.Queue(profile)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	for i, q := range queue {
+		now := time.Now()
+		if result.Err != nil || (!force && !q.Due(now)) {
+			result.Remaining++
+			continue
+		}
+
+		// Another flush, e.g. from a second ytrss, may have sent or
+		// removed the URL since the queue was read, or be sending it now.
+		// Claiming it in the same transaction as the check keeps two
+		// flushes from both sending it.
+		var claimed bool
+		err := withStore(path, func(s *Store) error {
+			var err error
+			q, claimed, err = s.claim(q.ID, now)
+			return err
+		})
+		if err != nil {
+			return result, err
+		}
+		if !claimed {
+			continue
+		}
+
+		item, sendErr := svc.AddUrlToPodcast(ctx, q.PodcastID, q.URL)
+		if err := ctx.Err(); err != nil {
+			result.Remaining += len(queue) - i
+			withStore(path, func(s *Store) error { return s.release(q) })
+			return result, err
+		}
+		if api.Temporary(sendErr) {
+			result.Err = sendErr
+			result.Remaining++
+			if err := withStore(path, func(s *Store) error { return s.retry(q, sendErr, now) }); err != nil {
+				return result, err
+			}
+			continue
+		}
+
+		r := Record{
+			URL:          q.URL,
+			VideoID:      q.VideoID,
+			PodcastID:    q.PodcastID,
+			PodcastTitle: q.PodcastTitle,
+			Profile:      q.Profile,
+			Submitted:    now,
+			Item:         item,
+		}
+		if sendErr != nil {
+			r.Error = sendErr.Error()
+		}
+		if err := withStore(path, func(s *Store) error { return s.complete(q, r) }); err != nil {
+			return result, err
+		}
+		if sendErr != nil {
+			result.Rejected = append(result.Rejected, r)
+		} else {
+			result.Sent = append(result.Sent, r)
+		}
+	}
+	return result, nil
+}
+
+// storeMu serializes withStore within the process, so that concurrent
+// callers wait for each other rather than for the file lock, which would
+// time out.
+var storeMu sync.Mutex
+
+// withStore opens the database at path for the duration of f only.
+func withStore(path string, f func(*Store) error) error {
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	s, err := Open(path)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	return f(s)
+}
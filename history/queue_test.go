@@ -0,0 +1,289 @@
+package history
+
+import (
+	"context"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lsherman98/ytrss-cli/api"
+)
+
+// addFunc is an api.Service that only supports AddUrlToPodcast.
+type addFunc func(ctx context.Context, podcastID, url string) (api.Item, error)
+
+func (f addFunc) AddUrlToPodcast(ctx context.Context, podcastID, url string) (api.Item, error) {
+	return f(ctx, podcastID, url)
+}
+
+func (f addFunc) ListPodcasts(context.Context) ([]api.Podcast, error) { panic("unexpected call") }
+
+func (f addFunc) GetPodcastItems(context.Context, string) ([]api.Item, error) {
+	panic("unexpected call")
+}
+
+func (f addFunc) GetUsage(context.Context) (*api.UsageResponse, error) { panic("unexpected call") }
+
+func (f addFunc) VerifyAPIKey(context.Context, string) (*api.UsageResponse, error) {
+	panic("unexpected call")
+}
+
+// queueTestPath returns the path of a database holding a queued URL for
+// each of urls, oldest first.
+func queueTestPath(t *testing.T, urls ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "history.db")
+	err := withStore(path, func(s *Store) error {
+		for i, url := range urls {
+			q := QueuedURL{URL: url, PodcastID: "p1", Queued: time.Now().Add(time.Duration(i-len(urls)) * time.Minute), Attempts: 1}
+			if _, err := s.Enqueue(q); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readQueue(t *testing.T, path string) []QueuedURL {
+	t.Helper()
+	var queue []QueuedURL
+	err := withStore(path, func(s *Store) error {
+		var err error
+		queue, err = s.Queue("")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return queue
+}
+
+func TestFlushSendsEachURLOnce(t *testing.T) {
+	path := queueTestPath(t, "https://youtu.be/dQw4w9WgXcQ")
+
+	var sends atomic.Int32
+	sending := make(chan struct{})
+	release := make(chan struct{})
+	slow := addFunc(func(ctx context.Context, podcastID, url string) (api.Item, error) {
+		sends.Add(1)
+		close(sending)
+		<-release
+		return api.Item{Status: api.StatusCreated}, nil
+	})
+
+	first := make(chan FlushResult)
+	go func() {
+		result, err := Flush(context.Background(), path, slow, "", true)
+		if err != nil {
+			t.Error(err)
+		}
+		first <- result
+	}()
+	<-sending
+
+	// A second flush, even a forced one, leaves the URL to the first.
+	result, err := Flush(context.Background(), path, addFunc(func(ctx context.Context, podcastID, url string) (api.Item, error) {
+		sends.Add(1)
+		return api.Item{Status: api.StatusCreated}, nil
+	}), "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Sent) != 0 {
+		t.Errorf("second flush sent %d URLs, want none", len(result.Sent))
+	}
+
+	close(release)
+	if result := <-first; len(result.Sent) != 1 {
+		t.Errorf("first flush sent %d URLs, want 1", len(result.Sent))
+	}
+	if n := sends.Load(); n != 1 {
+		t.Errorf("URL sent %d times, want once", n)
+	}
+	if queue := readQueue(t, path); len(queue) != 0 {
+		t.Errorf("queue = %+v, want it empty", queue)
+	}
+}
+
+func TestFlushReleasesClaimWhenCanceled(t *testing.T) {
+	path := queueTestPath(t, "https://youtu.be/dQw4w9WgXcQ")
+	ctx, cancel := context.WithCancel(context.Background())
+	_, err := Flush(ctx, path, addFunc(func(ctx context.Context, podcastID, url string) (api.Item, error) {
+		cancel()
+		return api.Item{}, ctx.Err()
+	}), "", true)
+	if err == nil {
+		t.Fatal("Flush succeeded after its context was canceled")
+	}
+
+	queue := readQueue(t, path)
+	if len(queue) != 1 || !queue[0].Claimed.IsZero() {
+		t.Errorf("queue = %+v, want the URL queued and unclaimed", queue)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{8, retryMaxDelay},
+		{100, retryMaxDelay},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempts); got != tt.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestFlushStopsAtFirstTemporaryError(t *testing.T) {
+	path := queueTestPath(t, "https://youtu.be/aaaaaaaaaaa", "https://youtu.be/bbbbbbbbbbb", "https://youtu.be/ccccccccccc")
+
+	var sends int
+	unreachable := &api.NetworkError{Err: context.DeadlineExceeded}
+	start := time.Now()
+	result, err := Flush(context.Background(), path, addFunc(func(ctx context.Context, podcastID, url string) (api.Item, error) {
+		sends++
+		if sends == 1 {
+			return api.Item{Status: api.StatusCreated, Created: "2024-05-01 12:00:00"}, nil
+		}
+		return api.Item{}, unreachable
+	}), "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sends != 2 {
+		t.Errorf("sent %d requests, want the flush to stop after the first failure", sends)
+	}
+	if len(result.Sent) != 1 || result.Sent[0].URL != "https://youtu.be/aaaaaaaaaaa" {
+		t.Errorf("Sent = %+v, want the first URL", result.Sent)
+	}
+	if result.Remaining != 2 || result.Err != unreachable {
+		t.Errorf("Remaining = %d, Err = %v, want 2 and the network error", result.Remaining, result.Err)
+	}
+
+	queue := readQueue(t, path)
+	if len(queue) != 2 {
+		t.Fatalf("queue = %+v, want the two unsent URLs", queue)
+	}
+	failed, untried := queue[0], queue[1]
+	if failed.Attempts != 2 || failed.LastError != unreachable.Error() {
+		t.Errorf("failed URL = %+v, want a second attempt with its error", failed)
+	}
+	if d := failed.NextAttempt.Sub(start); d < retryDelay(2) || d > retryDelay(2)+time.Minute {
+		t.Errorf("NextAttempt is %s after the flush, want %s", d, retryDelay(2))
+	}
+	if untried.Attempts != 1 || untried.LastError != "" || !untried.NextAttempt.IsZero() {
+		t.Errorf("untried URL = %+v, want it unchanged", untried)
+	}
+	for _, q := range queue {
+		if !q.Claimed.IsZero() {
+			t.Errorf("%s is still claimed", q.URL)
+		}
+	}
+}
+
+func TestFlushOnlyDueUnlessForced(t *testing.T) {
+	path := queueTestPath(t, "https://youtu.be/aaaaaaaaaaa")
+	err := withStore(path, func(s *Store) error {
+		queue, err := s.Queue("")
+		if err != nil {
+			return err
+		}
+		return s.retry(queue[0], &api.NetworkError{Err: context.DeadlineExceeded}, time.Now())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sends int
+	svc := addFunc(func(ctx context.Context, podcastID, url string) (api.Item, error) {
+		sends++
+		return api.Item{Status: api.StatusCreated}, nil
+	})
+
+	result, err := Flush(context.Background(), path, svc, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sends != 0 || result.Remaining != 1 {
+		t.Errorf("sent %d with %d remaining, want the URL left until it is due", sends, result.Remaining)
+	}
+
+	result, err = Flush(context.Background(), path, svc, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sends != 1 || len(result.Sent) != 1 || result.Remaining != 0 {
+		t.Errorf("forced flush sent %d with %d remaining, want the URL sent", sends, result.Remaining)
+	}
+}
+
+func TestFlushRecordsRejectedURL(t *testing.T) {
+	path := queueTestPath(t, "https://youtu.be/aaaaaaaaaaa")
+	refused := &api.APIError{StatusCode: 422, Message: "video is private"}
+
+	result, err := Flush(context.Background(), path, addFunc(func(ctx context.Context, podcastID, url string) (api.Item, error) {
+		return api.Item{}, refused
+	}), "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Rejected) != 1 || result.Remaining != 0 || result.Err != nil {
+		t.Fatalf("result = %+v, want the URL rejected", result)
+	}
+	if queue := readQueue(t, path); len(queue) != 0 {
+		t.Errorf("queue = %+v, want it empty", queue)
+	}
+
+	var records []Record
+	err = withStore(path, func(s *Store) error {
+		var err error
+		records, err = s.Query(Filter{})
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Status() != StatusFailed || records[0].Error != refused.Error() {
+		t.Errorf("history = %+v, want the URL recorded as failed", records)
+	}
+}
+
+func TestFlushDoesNotRequeueRemovedURL(t *testing.T) {
+	path := queueTestPath(t, "https://youtu.be/aaaaaaaaaaa")
+
+	result, err := Flush(context.Background(), path, addFunc(func(ctx context.Context, podcastID, url string) (api.Item, error) {
+		// The user removes the URL while the request is in flight.
+		err := withStore(path, func(s *Store) error {
+			queue, err := s.Queue("")
+			if err != nil {
+				return err
+			}
+			return s.Remove(queue[0].ID)
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		return api.Item{}, &api.NetworkError{Err: context.DeadlineExceeded}
+	}), "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Remaining != 1 {
+		t.Errorf("Remaining = %d, want the failed attempt counted", result.Remaining)
+	}
+	if queue := readQueue(t, path); len(queue) != 0 {
+		t.Errorf("queue = %+v, want the removed URL to stay removed", queue)
+	}
+}
@@ -0,0 +1,80 @@
+package history
+
+import (
+	"time"
+
+	"github.com/lsherman98/ytrss-cli/api"
+)
+
+// Submission is a URL a profile submits to a podcast.
+type Submission struct {
+	URL     string
+	VideoID string
+	Podcast api.Podcast
+	Profile string
+}
+
+// Record returns the history record of s, which the server answered with
+// item or err.
+func (s Submission) Record(item api.Item, err error) Record {
+	r := Record{
+		URL:          s.URL,
+		VideoID:      s.VideoID,
+		PodcastID:    s.Podcast.ID,
+		PodcastTitle: s.Podcast.Title,
+		Profile:      s.Profile,
+		Submitted:    time.Now(),
+		Item:         item,
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// Queued returns s as a queued URL whose first attempt failed with err.
+func (s Submission) Queued(err error) QueuedURL {
+	return QueuedURL{
+		URL:          s.URL,
+		VideoID:      s.VideoID,
+		PodcastID:    s.Podcast.ID,
+		PodcastTitle: s.Podcast.Title,
+		Profile:      s.Profile,
+		Queued:       time.Now(),
+		Attempts:     1,
+		LastError:    err.Error(),
+	}
+}
+
+// RecordSubmission adds s, answered with item or err, to the history at
+// DefaultPath.
+func RecordSubmission(s Submission, item api.Item, err error) error {
+	return withDefaultStore(func(store *Store) error {
+		return store.Add(s.Record(item, err))
+	})
+}
+
+// QueueSubmission queues s in the history at DefaultPath after its first
+// attempt failed with err because the API could not be reached.
+func QueueSubmission(s Submission, err error) error {
+	return withDefaultStore(func(store *Store) error {
+		_, err := store.Enqueue(s.Queued(err))
+		return err
+	})
+}
+
+// UpdateDefault stores the latest state of a podcast's items in the
+// history at DefaultPath, see Store.UpdateItems.
+func UpdateDefault(podcastID string, items []api.Item) error {
+	return withDefaultStore(func(store *Store) error {
+		return store.UpdateItems(podcastID, items)
+	})
+}
+
+func withDefaultStore(f func(*Store) error) error {
+	path, err := DefaultPath()
+	if err != nil {
+		return err
+	}
+	return withStore(path, f)
+}
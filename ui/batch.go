@@ -0,0 +1,213 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lsherman98/ytrss-cli/api"
+	"github.com/lsherman98/ytrss-cli/history"
+	"github.com/lsherman98/ytrss-cli/youtube"
+)
+
+// Submission tracks a URL submitted from ViewEnterURL. Item is nil until
+// the server has responded. Queued URLs keep the error that queued them.
+type Submission struct {
+	URL    string
+	Item   *api.Item
+	Err    error
+	Queued bool
+}
+
+type batchLine struct {
+	Line int
+	URL  string
+	Err  error
+}
+
+// parseBatch splits the batch input into one URL per non-blank line and
+// normalizes each of them. Lines that fail validation keep their input.
+func parseBatch(value string) []batchLine {
+	var lines []batchLine
+	for i, line := range strings.Split(value, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		normalized, err := youtube.Normalize(line)
+		if err != nil {
+			normalized = line
+		}
+		lines = append(lines, batchLine{Line: i + 1, URL: normalized, Err: err})
+	}
+	return lines
+}
+
+// startSubmission clears the URL inputs and submits urls. Batches move
+// straight to the items table; single URLs wait there for the response so
+// errors can be shown next to the input.
+func (m *Model) startSubmission(urls []string, batch bool) tea.Cmd {
+	m.UrlInput.SetValue("")
+	m.BatchInput.Reset()
+	m.Error = ""
+	m.Message = ""
+	if batch {
+		m.navigate(ViewItemsTable)
+	} else {
+		m.navigate(ViewEnterURL)
+	}
+	return m.submitURLs(urls)
+}
+
+func (m *Model) submitURLs(urls []string) tea.Cmd {
+	m.Submissions = make([]Submission, len(urls))
+	cmds := make([]tea.Cmd, len(urls))
+	for i, u := range urls {
+		m.Submissions[i] = Submission{URL: u}
+		cmds[i] = AddURL(m.ctx, m.Profile.Service, m.Profile.Name, *m.SelectedPodcast, u)
+	}
+	return tea.Batch(cmds...)
+}
+
+// recordSubmission stores the server response for the first outstanding
+// submission of msg.URL. It reports false for responses that do not belong
+// to the current submissions.
+func (m *Model) recordSubmission(msg UrlAddedMsg) bool {
+	for i := range m.Submissions {
+		s := &m.Submissions[i]
+		if s.URL != msg.URL || s.Item != nil || s.Err != nil {
+			continue
+		}
+		if msg.Err != nil {
+			s.Err = msg.Err
+			s.Queued = msg.Queued
+		} else {
+			item := msg.Item
+			s.Item = &item
+		}
+		return true
+	}
+	return false
+}
+
+// recordFlush updates the queued submissions a flush has sent.
+func (m *Model) recordFlush(result history.FlushResult) {
+	for i := range m.Submissions {
+		s := &m.Submissions[i]
+		if !s.Queued {
+			continue
+		}
+		for _, r := range slices.Concat(result.Sent, result.Rejected) {
+			if r.URL != s.URL || r.PodcastID != m.SelectedPodcast.ID {
+				continue
+			}
+			s.Queued = false
+			if r.Error != "" {
+				s.Err = errors.New(r.Error)
+			} else {
+				item := r.Item
+				s.Item, s.Err = &item, nil
+			}
+			break
+		}
+	}
+}
+
+func (m Model) submissionsInFlight() bool {
+	for _, s := range m.Submissions {
+		if s.Item == nil && s.Err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (m Model) isTracked(item api.Item) bool {
+	if item.Created == "" {
+		return false
+	}
+	for _, s := range m.Submissions {
+		if s.Item != nil && s.Item.Created == item.Created {
+			return true
+		}
+	}
+	return false
+}
+
+// trackedStatus returns the latest known status of a submitted item,
+// preferring the polled items over the initial server response.
+func (m Model) trackedStatus(s Submission) string {
+	if s.Item.Created != "" {
+		for _, item := range m.Items {
+			if item.Created == s.Item.Created {
+				return item.Status
+			}
+		}
+	}
+	return s.Item.Status
+}
+
+func (m Model) submissionSummary() string {
+	var sending, processing, succeeded, failed, queued, notSubmitted int
+	for _, s := range m.Submissions {
+		switch {
+		case s.Queued:
+			queued++
+		case s.Err != nil:
+			notSubmitted++
+		case s.Item == nil:
+			sending++
+		default:
+			switch m.trackedStatus(s) {
+			case api.StatusSuccess:
+				succeeded++
+			case api.StatusError:
+				failed++
+			default:
+				processing++
+			}
+		}
+	}
+
+	parts := []string{fmt.Sprintf("Tracking %d submitted URL(s)", len(m.Submissions))}
+	if sending > 0 {
+		parts = append(parts, fmt.Sprintf("%d sending", sending))
+	}
+	if processing > 0 {
+		parts = append(parts, fmt.Sprintf("%d processing", processing))
+	}
+	if succeeded > 0 {
+		parts = append(parts, fmt.Sprintf("%d succeeded", succeeded))
+	}
+	if failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", failed))
+	}
+	if queued > 0 {
+		parts = append(parts, fmt.Sprintf("%d queued", queued))
+	}
+	if notSubmitted > 0 {
+		parts = append(parts, fmt.Sprintf("%d not submitted", notSubmitted))
+	}
+	return strings.Join(parts, " • ")
+}
+
+func (m Model) failedSubmissions() []Submission {
+	var failed []Submission
+	for _, s := range m.Submissions {
+		if s.Err != nil && !s.Queued {
+			failed = append(failed, s)
+		}
+	}
+	return failed
+}
+
+func (m *Model) focusURLInput() tea.Cmd {
+	if m.BatchMode {
+		m.UrlInput.Blur()
+		return m.BatchInput.Focus()
+	}
+	m.BatchInput.Blur()
+	return m.UrlInput.Focus()
+}
@@ -0,0 +1,34 @@
+package ui
+
+import (
+	"errors"
+
+	"github.com/lsherman98/ytrss-cli/api"
+)
+
+// showError displays err with guidance for the causes the user can act on.
+// A missing or rejected API key sends the user to ViewSetAPIKey.
+func (m *Model) showError(err error) {
+	var netErr *api.NetworkError
+	switch {
+	case errors.Is(err, api.ErrNoAPIKey):
+		m.HasAPIKey = false
+		m.Error = "No API key is set. Enter your API key to continue."
+		m.navigate(ViewSetAPIKey)
+		m.ApiKeyInput.Focus()
+	case errors.Is(err, api.ErrUnauthorized):
+		m.Error = "Your API key was rejected. Enter a valid API key."
+		m.navigate(ViewSetAPIKey)
+		m.ApiKeyInput.Focus()
+	case errors.Is(err, api.ErrQuotaExceeded):
+		m.Error = "You have reached your usage limit. Wait for it to reset or upgrade your plan."
+	case errors.Is(err, api.ErrNotFound):
+		m.Error = "The podcast could not be found. It may have been deleted."
+	case errors.As(err, &netErr) && netErr.Timeout():
+		m.Error = "The server took too long to respond. Try again later."
+	case errors.As(err, &netErr):
+		m.Error = "Could not reach the server. Check your connection and try again."
+	default:
+		m.Error = err.Error()
+	}
+}
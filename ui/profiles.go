@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lsherman98/ytrss-cli/api"
+)
+
+// Profile is an account the TUI works with.
+type Profile struct {
+	Name           string
+	Service        api.Service
+	Credentials    api.CredentialProvider
+	DefaultPodcast string
+}
+
+// ProfileLoader opens the named profile for the profile switcher.
+type ProfileLoader func(name string) (Profile, error)
+
+func newProfileList(names []string, current string) list.Model {
+	items := make([]list.Item, len(names))
+	selected := 0
+	for i, name := range names {
+		items[i] = menuItem(name)
+		if name == current {
+			selected = i
+		}
+	}
+	l := list.New(items, itemDelegate{}, 30, max(len(names)+2, 5))
+	l.Title = "Switch Profile"
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(false)
+	l.SetShowHelp(false)
+	l.Styles.Title = TitleStyle
+	l.Select(selected)
+	return l
+}
+
+// switchProfile replaces the service and credentials with those of the
+// named profile and checks whether it has an API key.
+func (m *Model) switchProfile(name string) tea.Cmd {
+	p, err := m.LoadProfile(name)
+	if err != nil {
+		m.Error = err.Error()
+		return nil
+	}
+
+	m.navigate(ViewMainMenu)
+	m.Profile = p
+	m.Usage = nil
+	m.Podcasts = nil
+	m.SelectedPodcast = nil
+	m.Queued = 0
+	m.flushing = true
+	m.Error = ""
+	m.Message = "Switched to profile " + p.Name
+	return tea.Batch(CheckAPIKey(m.ctx, p.Credentials), FlushQueue(p.Service, p.Name, false))
+}
+
+// forgetAPIKey makes the service read the API key again after it was
+// changed, for services that cache it such as *api.Client.
+func (m *Model) forgetAPIKey() {
+	if c, ok := m.Profile.Service.(interface{ ForgetAPIKey() }); ok {
+		c.ForgetAPIKey()
+	}
+}
+
+// selectDefaultPodcast moves the podcast table cursor to the profile's
+// default podcast, matched by ID or title.
+func (m *Model) selectDefaultPodcast() {
+	if m.Profile.DefaultPodcast == "" {
+		return
+	}
+	for i, p := range m.Podcasts {
+		if p.ID == m.Profile.DefaultPodcast || strings.EqualFold(strings.TrimSpace(p.Title), strings.TrimSpace(m.Profile.DefaultPodcast)) {
+			m.PodcastTable.SetCursor(i)
+			return
+		}
+	}
+}
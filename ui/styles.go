@@ -1,6 +1,11 @@
 package ui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
+)
 
 var (
 	TitleStyle = lipgloss.NewStyle().
@@ -19,4 +24,21 @@ var (
 	SuccessStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#04B575")).
 			Bold(true)
+
+	BannerStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#7D56F4")).
+			Italic(true)
 )
+
+// SetTheme selects the colour theme: "default", or "mono" to render
+// without colours.
+func SetTheme(name string) error {
+	switch name {
+	case "", "default":
+	case "mono":
+		lipgloss.SetColorProfile(termenv.Ascii)
+	default:
+		return fmt.Errorf("unknown theme %q", name)
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package ui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
@@ -10,10 +12,13 @@ import (
 	"github.com/charmbracelet/bubbles/progress"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textarea"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/lsherman98/ytrss-cli/api"
+	"github.com/lsherman98/ytrss-cli/history"
+	"github.com/lsherman98/ytrss-cli/youtube"
 )
 
 type ViewState int
@@ -24,7 +29,10 @@ const (
 	ViewSelectPodcast
 	ViewEnterURL
 	ViewItemsTable
+	ViewConfirmDuplicates
 	ViewFatalError
+	ViewSelectProfile
+	ViewHistory
 )
 
 type FatalErrorMsg struct {
@@ -35,18 +43,43 @@ type ApiKeyCheckedMsg struct {
 	HasKey bool
 }
 
+type ApiKeyVerifiedMsg struct {
+	Key   string
+	Usage *api.UsageResponse
+	Err   error
+}
+
+// PodcastsLoadedMsg holds the podcasts, taken from the history when
+// Offline.
 type PodcastsLoadedMsg struct {
 	Podcasts []api.Podcast
+	Offline  bool
 	Err      error
 }
 
+// UrlAddedMsg is the response to a submission. Queued is set when the API
+// could not be reached and the URL was queued to be sent later.
 type UrlAddedMsg struct {
-	Item api.Item
-	Err  error
+	URL    string
+	Item   api.Item
+	Err    error
+	Queued bool
+}
+
+// DuplicatesCheckedMsg holds, for each of URLs, the reason it is a
+// duplicate or "" if it is not.
+type DuplicatesCheckedMsg struct {
+	URLs       []string
+	Duplicates []string
+	Batch      bool
+	Err        error
 }
 
+// ItemsLoadedMsg holds the podcast's items and the URLs queued for it,
+// which are loaded even when the items are not.
 type ItemsLoadedMsg struct {
 	Items []api.Item
+	Queue []history.QueuedURL
 	Err   error
 }
 
@@ -55,7 +88,26 @@ type UsageLoadedMsg struct {
 	Err   error
 }
 
-type TickMsg time.Time
+type HistoryLoadedMsg struct {
+	Records []history.Record
+	Err     error
+}
+
+// QueueFlushedMsg reports a flush of the queue of Profile, which then
+// holds Queued URLs.
+type QueueFlushedMsg struct {
+	Profile string
+	Result  history.FlushResult
+	Queued  int
+	Err     error
+}
+
+type UpdateCheckedMsg struct {
+	Notice string
+	Err    error
+}
+
+type QueueTickMsg time.Time
 
 type menuItem string
 
@@ -82,16 +134,28 @@ func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 }
 
 type Model struct {
+	Profile         Profile
+	ProfileNames    []string
+	LoadProfile     ProfileLoader
+	ProfileList     list.Model
 	State           ViewState
 	HasAPIKey       bool
+	Verifying       bool
 	ApiKeyInput     textinput.Model
 	UrlInput        textinput.Model
+	BatchInput      textarea.Model
+	BatchMode       bool
+	Submissions     []Submission
+	Pending         *DuplicatesCheckedMsg
 	MainMenu        list.Model
 	PodcastTable    table.Model
 	ItemsTable      table.Model
 	Podcasts        []api.Podcast
 	SelectedPodcast *api.Podcast
 	Items           []api.Item
+	Queue           []history.QueuedURL
+	History         []history.Record
+	HistoryTable    table.Model
 	Spinner         spinner.Model
 	ProgressBar     progress.Model
 	Usage           *api.UsageResponse
@@ -100,9 +164,28 @@ type Model struct {
 	Width           int
 	Height          int
 	Polling         bool
+	PollInterval    time.Duration
+	// HistoryStatus filters the history to one of history.Statuses, or
+	// shows every submission when empty.
+	HistoryStatus string
+	// Queued counts the profile's URLs waiting to be sent, which are
+	// flushed periodically and as soon as a request succeeds again.
+	Queued   int
+	flushing bool
+	// CheckUpdate, when set, runs in the background at startup and returns
+	// a notice to show in the banner.
+	CheckUpdate  func(ctx context.Context) (string, error)
+	UpdateNotice string
+
+	// ctx scopes the requests of the current view and is cancelled when
+	// the user leaves it.
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-func InitialModel() Model {
+// InitialModel starts the TUI with profile. names lists the profiles the
+// user can switch to, opened with load.
+func InitialModel(profile Profile, names []string, load ProfileLoader) Model {
 	apiKeyInput := textinput.New()
 	apiKeyInput.Placeholder = "Enter your API key"
 	apiKeyInput.Focus()
@@ -114,10 +197,19 @@ func InitialModel() Model {
 	urlInput.CharLimit = 500
 	urlInput.Width = 80
 
+	batchInput := textarea.New()
+	batchInput.Placeholder = "Paste YouTube URLs here, one per line"
+	batchInput.SetWidth(80)
+	batchInput.SetHeight(8)
+
 	items := []list.Item{
 		menuItem("Add YouTube URL"),
+		menuItem("History"),
 		menuItem("Set API Key"),
 	}
+	if len(names) > 1 {
+		items = append(items, menuItem("Switch Profile"))
+	}
 	mainMenu := list.New(items, itemDelegate{}, 30, 8)
 	mainMenu.Title = "Main Menu"
 	mainMenu.SetShowStatusBar(false)
@@ -132,18 +224,55 @@ func InitialModel() Model {
 	prog := progress.New(progress.WithDefaultGradient())
 	prog.Width = 40
 
+	ctx, cancel := context.WithCancel(context.Background())
+
 	return Model{
-		State:       ViewSetAPIKey,
-		ApiKeyInput: apiKeyInput,
-		UrlInput:    urlInput,
-		MainMenu:    mainMenu,
-		Spinner:     s,
-		ProgressBar: prog,
+		Profile:      profile,
+		PollInterval: api.PollInterval,
+		ProfileNames: names,
+		LoadProfile:  load,
+		ctx:          ctx,
+		cancel:       cancel,
+		State:        ViewSetAPIKey,
+		ApiKeyInput:  apiKeyInput,
+		UrlInput:     urlInput,
+		BatchInput:   batchInput,
+		MainMenu:     mainMenu,
+		Spinner:      s,
+		ProgressBar:  prog,
 	}
 }
 
+// navigate switches to state, cancelling requests still running for the
+// view being left.
+func (m *Model) navigate(state ViewState) {
+	if state == m.State {
+		return
+	}
+	m.cancel()
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	m.State = state
+}
+
+func (m *Model) quit() tea.Cmd {
+	m.cancel()
+	return tea.Quit
+}
+
+func canceled(err error) bool {
+	return errors.Is(err, context.Canceled)
+}
+
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(CheckAPIKey, m.Spinner.Tick)
+	cmds := []tea.Cmd{
+		CheckAPIKey(m.ctx, m.Profile.Credentials),
+		m.Spinner.Tick,
+		func() tea.Msg { return QueueTickMsg(time.Now()) },
+	}
+	if m.CheckUpdate != nil {
+		cmds = append(cmds, CheckForUpdate(m.CheckUpdate))
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -154,7 +283,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case FatalErrorMsg:
 		m.Error = msg.Err.Error()
 		m.State = ViewFatalError
-		return m, tea.Quit
+		return m, m.quit()
 
 	case tea.WindowSizeMsg:
 		m.Width = msg.Width
@@ -164,74 +293,190 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.HasAPIKey = msg.HasKey
 		if msg.HasKey {
 			m.State = ViewMainMenu
-			return m, LoadUsage()
+			return m, LoadUsage(m.ctx, m.Profile.Service)
 		} else {
 			m.State = ViewSetAPIKey
 			m.ApiKeyInput.Focus()
 		}
 
+	case ApiKeyVerifiedMsg:
+		m.Verifying = false
+		if canceled(msg.Err) {
+			break
+		}
+		// A rejected key is never stored. When the server could not be
+		// reached the key is saved anyway, with a warning.
+		if errors.Is(msg.Err, api.ErrUnauthorized) {
+			m.Error = "The server rejected this API key, it was not saved"
+			break
+		}
+		if err := api.SetApiKey(m.Profile.Name, msg.Key); err != nil {
+			m.Error = err.Error()
+			break
+		}
+		m.forgetAPIKey()
+		m.HasAPIKey = true
+		m.ApiKeyInput.SetValue("")
+		m.navigate(ViewMainMenu)
+		if msg.Err != nil {
+			m.Message = ""
+			m.Error = "API key saved, but it could not be verified: " + msg.Err.Error()
+			break
+		}
+		m.Usage = msg.Usage
+		m.Error = ""
+		m.Message = "API key verified and saved! Usage: " + msg.Usage.String()
+
+	case HistoryLoadedMsg:
+		if m.State != ViewHistory {
+			break
+		}
+		if msg.Err != nil {
+			m.showError(msg.Err)
+			break
+		}
+		m.History = msg.Records
+		m.Error = ""
+		m.buildHistoryTable()
+
+	case UpdateCheckedMsg:
+		// A failed check is not worth interrupting the user for.
+		if msg.Err == nil {
+			m.UpdateNotice = msg.Notice
+		}
+
 	case UsageLoadedMsg:
+		if canceled(msg.Err) {
+			break
+		}
 		if msg.Err != nil {
-			m.Error = msg.Err.Error()
+			m.showError(msg.Err)
 		} else {
 			m.Usage = msg.Usage
+			cmds = append(cmds, m.flushQueue())
 		}
 
 	case PodcastsLoadedMsg:
+		if canceled(msg.Err) {
+			break
+		}
 		if msg.Err != nil {
-			m.Error = msg.Err.Error()
 			m.State = ViewMainMenu
+			m.showError(msg.Err)
 		} else {
 			m.Podcasts = msg.Podcasts
 			m.Error = ""
+			if msg.Offline {
+				m.Error = "Could not connect to the API, showing the podcasts in your history. URLs will be queued."
+			} else {
+				cmds = append(cmds, m.flushQueue())
+			}
 			m.buildPodcastTable()
+			m.selectDefaultPodcast()
 			m.State = ViewSelectPodcast
 		}
 
 	case UrlAddedMsg:
-		if msg.Err != nil {
-			m.Error = msg.Err.Error()
+		if canceled(msg.Err) || !m.recordSubmission(msg) {
+			break
+		}
+		if msg.Queued {
+			m.Queued++
+			if len(m.Submissions) == 1 {
+				m.Message = "Could not connect to the API, the URL was queued and will be sent when the connection is back"
+			} else if !m.Polling {
+				// Show the queued URL in the items table.
+				m.Polling = true
+				cmds = append(cmds, m.pollItems(true))
+			}
+		} else if msg.Err != nil {
+			if len(m.Submissions) == 1 {
+				m.showError(msg.Err)
+			}
 		} else {
 			m.Error = ""
-			m.State = ViewItemsTable
-			m.Polling = true
-			cmds = append(cmds, LoadItems(m.SelectedPodcast.ID))
+			if m.State == ViewEnterURL {
+				m.State = ViewItemsTable
+			}
+			if !m.Polling {
+				m.Polling = true
+				cmds = append(cmds, m.pollItems(true))
+			}
+			cmds = append(cmds, m.flushQueue())
+		}
+
+	case DuplicatesCheckedMsg:
+		if m.State != ViewEnterURL || canceled(msg.Err) {
+			break
+		}
+		if msg.Err != nil {
+			m.showError(msg.Err)
+			break
+		}
+		for _, reason := range msg.Duplicates {
+			if reason != "" {
+				m.Pending = &msg
+				m.State = ViewConfirmDuplicates
+				return m, nil
+			}
 		}
+		return m, m.startSubmission(msg.URLs, msg.Batch)
 
 	case ItemsLoadedMsg:
+		if canceled(msg.Err) {
+			break
+		}
+		m.Queue = msg.Queue
 		if msg.Err != nil {
-			m.Error = msg.Err.Error()
 			m.Polling = false
+			m.showError(msg.Err)
+			m.buildItemsTable()
 		} else {
 			m.Items = msg.Items
+			m.Error = ""
 			m.buildItemsTable()
+			cmds = append(cmds, m.flushQueue())
 
-			hasCreated := false
-			allSuccess := true
-			for _, item := range m.Items {
-				if item.Status == "CREATED" {
-					hasCreated = true
-					allSuccess = false
-					break
-				}
-				if item.Status != "SUCCESS" {
-					allSuccess = false
-				}
-			}
-
-			if hasCreated && m.Polling {
-				cmds = append(cmds, tick())
+			if (api.HasPending(m.Items) || m.submissionsInFlight()) && m.Polling {
+				cmds = append(cmds, m.pollItems(false))
 			} else {
 				m.Polling = false
-				if allSuccess && len(m.Items) > 0 {
-					cmds = append(cmds, LoadUsage())
+				if api.AllSucceeded(m.Items) {
+					cmds = append(cmds, LoadUsage(m.ctx, m.Profile.Service))
 				}
 			}
 		}
 
-	case TickMsg:
-		if m.Polling && m.SelectedPodcast != nil {
-			cmds = append(cmds, LoadItems(m.SelectedPodcast.ID))
+	case QueueTickMsg:
+		cmds = append(cmds, queueTick())
+		if !m.flushing {
+			m.flushing = true
+			cmds = append(cmds, FlushQueue(m.Profile.Service, m.Profile.Name, false))
+		}
+
+	case QueueFlushedMsg:
+		if msg.Profile != m.Profile.Name {
+			break
+		}
+		m.flushing = false
+		if msg.Err != nil {
+			break
+		}
+		m.Queued = msg.Queued
+		sent, rejected := len(msg.Result.Sent), len(msg.Result.Rejected)
+		if sent+rejected == 0 {
+			break
+		}
+		m.Message = fmt.Sprintf("Sent %d queued URL(s)", sent+rejected)
+		if rejected > 0 {
+			m.Message += fmt.Sprintf(", %d rejected (see History)", rejected)
+		}
+		if m.SelectedPodcast != nil {
+			m.recordFlush(msg.Result)
+		}
+		if m.State == ViewItemsTable && m.SelectedPodcast != nil && !m.Polling {
+			m.Polling = true
+			cmds = append(cmds, m.pollItems(true))
 		}
 
 	case tea.KeyMsg:
@@ -240,32 +485,27 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch msg.String() {
 			case "ctrl+c", "esc":
 				if m.HasAPIKey {
-					m.State = ViewMainMenu
+					m.navigate(ViewMainMenu)
 					return m, nil
 				}
-				return m, tea.Quit
+				return m, m.quit()
 			case "ctrl+d":
-				err := api.ClearApiKey()
+				err := api.ClearApiKey(m.Profile.Name)
 				if err != nil {
 					m.Error = err.Error()
 				} else {
+					m.forgetAPIKey()
 					m.HasAPIKey = false
 					m.Message = "API key cleared successfully!"
 					m.Error = ""
 				}
 				return m, nil
 			case "enter":
-				if m.ApiKeyInput.Value() != "" {
-					err := api.SetApiKey(m.ApiKeyInput.Value())
-					if err != nil {
-						m.Error = err.Error()
-					} else {
-						m.HasAPIKey = true
-						m.Message = "API key saved successfully!"
-						m.ApiKeyInput.SetValue("")
-						m.State = ViewMainMenu
-						return m, LoadUsage()
-					}
+				if key := strings.TrimSpace(m.ApiKeyInput.Value()); key != "" && !m.Verifying {
+					m.Verifying = true
+					m.Error = ""
+					m.Message = ""
+					return m, VerifyAPIKey(m.ctx, m.Profile.Service, key)
 				}
 				return m, nil
 			}
@@ -273,74 +513,177 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case ViewMainMenu:
 			switch msg.String() {
 			case "ctrl+c", "q":
-				return m, tea.Quit
+				return m, m.quit()
 			case "enter":
 				selected := m.MainMenu.SelectedItem()
 				if selected != nil {
 					switch selected.(menuItem) {
 					case "Set API Key":
-						m.State = ViewSetAPIKey
+						m.navigate(ViewSetAPIKey)
 						m.ApiKeyInput.Focus()
 						m.Error = ""
 						m.Message = ""
+					case "Switch Profile":
+						m.navigate(ViewSelectProfile)
+						m.ProfileList = newProfileList(m.ProfileNames, m.Profile.Name)
+						m.Error = ""
+						m.Message = ""
 					case "Add YouTube URL":
-						m.State = ViewSelectPodcast
+						m.navigate(ViewSelectPodcast)
+						m.Error = ""
+						m.Message = ""
+						return m, LoadPodcasts(m.ctx, m.Profile.Service, m.Profile.Name)
+					case "History":
+						m.navigate(ViewHistory)
 						m.Error = ""
 						m.Message = ""
-						return m, LoadPodcasts
+						m.History = nil
+						m.HistoryStatus = ""
+						m.buildHistoryTable()
+						return m, LoadHistory(m.Profile.Name, m.HistoryStatus)
 					}
 				}
 			}
 
+		case ViewSelectProfile:
+			switch msg.String() {
+			case "ctrl+c", "q":
+				return m, m.quit()
+			case "esc":
+				m.navigate(ViewMainMenu)
+				return m, nil
+			case "enter":
+				if selected, ok := m.ProfileList.SelectedItem().(menuItem); ok {
+					return m, m.switchProfile(string(selected))
+				}
+				return m, nil
+			}
+
 		case ViewSelectPodcast:
 			switch msg.String() {
 			case "ctrl+c", "q":
-				return m, tea.Quit
+				return m, m.quit()
 			case "esc":
-				m.State = ViewMainMenu
+				m.navigate(ViewMainMenu)
 				return m, nil
 			case "enter":
 				if m.PodcastTable.Cursor() < len(m.Podcasts) {
 					m.SelectedPodcast = &m.Podcasts[m.PodcastTable.Cursor()]
-					m.State = ViewEnterURL
-					m.UrlInput.Focus()
+					m.navigate(ViewEnterURL)
 					m.UrlInput.SetValue("")
-					return m, nil
+					m.BatchInput.Reset()
+					return m, m.focusURLInput()
 				}
 			}
 
 		case ViewEnterURL:
 			switch msg.String() {
-			case "ctrl+c", "q":
-				return m, tea.Quit
+			case "ctrl+c":
+				return m, m.quit()
+			case "q":
+				if !m.BatchMode {
+					return m, m.quit()
+				}
 			case "esc":
-				m.State = ViewSelectPodcast
+				m.navigate(ViewSelectPodcast)
 				m.UrlInput.Blur()
+				m.BatchInput.Blur()
 				return m, nil
+			case "tab":
+				m.BatchMode = !m.BatchMode
+				m.Error = ""
+				return m, m.focusURLInput()
+			case "ctrl+s":
+				if m.BatchMode && m.SelectedPodcast != nil {
+					lines := parseBatch(m.BatchInput.Value())
+					var urls []string
+					for _, line := range lines {
+						if line.Err != nil {
+							m.Error = "Fix or remove the invalid lines before submitting"
+							return m, nil
+						}
+						urls = append(urls, line.URL)
+					}
+					if len(urls) == 0 {
+						return m, nil
+					}
+					m.Error = ""
+					return m, CheckDuplicates(m.ctx, m.Profile.Service, m.SelectedPodcast.ID, urls, true)
+				}
 			case "enter":
-				if m.UrlInput.Value() != "" && m.SelectedPodcast != nil {
-					url := m.UrlInput.Value()
+				if !m.BatchMode && m.UrlInput.Value() != "" && m.SelectedPodcast != nil {
+					url, err := youtube.Normalize(m.UrlInput.Value())
+					if err != nil {
+						m.Error = err.Error()
+						return m, nil
+					}
+					m.Error = ""
+					return m, CheckDuplicates(m.ctx, m.Profile.Service, m.SelectedPodcast.ID, []string{url}, false)
+				}
+			}
+
+		case ViewConfirmDuplicates:
+			switch msg.String() {
+			case "ctrl+c":
+				return m, m.quit()
+			case "esc", "n":
+				m.navigate(ViewEnterURL)
+				m.Pending = nil
+				return m, m.focusURLInput()
+			case "y":
+				pending := m.Pending
+				m.Pending = nil
+				return m, m.startSubmission(pending.URLs, pending.Batch)
+			case "s":
+				pending := m.Pending
+				m.Pending = nil
+				var urls []string
+				for i, u := range pending.URLs {
+					if pending.Duplicates[i] == "" {
+						urls = append(urls, u)
+					}
+				}
+				if len(urls) == 0 {
+					m.navigate(ViewEnterURL)
 					m.UrlInput.SetValue("")
-					return m, AddURL(m.SelectedPodcast.ID, url)
+					m.BatchInput.Reset()
+					m.Message = "Nothing left to submit"
+					return m, m.focusURLInput()
 				}
+				return m, m.startSubmission(urls, pending.Batch)
+			}
+			return m, nil
+
+		case ViewHistory:
+			switch msg.String() {
+			case "ctrl+c", "q":
+				return m, m.quit()
+			case "esc", "m":
+				m.navigate(ViewMainMenu)
+				return m, nil
+			case "s":
+				m.HistoryStatus = nextStatus(m.HistoryStatus)
+				return m, LoadHistory(m.Profile.Name, m.HistoryStatus)
 			}
 
 		case ViewItemsTable:
 			switch msg.String() {
 			case "ctrl+c", "q":
 				m.Polling = false
-				return m, tea.Quit
+				return m, m.quit()
 			case "a":
-				m.State = ViewEnterURL
-				m.UrlInput.Focus()
+				m.navigate(ViewEnterURL)
 				m.UrlInput.SetValue("")
 				m.Polling = false
-				return m, nil
+				m.Submissions = nil
+				return m, m.focusURLInput()
 			case "m":
-				m.State = ViewMainMenu
+				m.navigate(ViewMainMenu)
 				m.Polling = false
+				m.Submissions = nil
 				m.SelectedPodcast = nil
-				return m, LoadUsage()
+				m.Queue = nil
+				return m, LoadUsage(m.ctx, m.Profile.Service)
 			}
 		}
 	}
@@ -352,21 +695,31 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ViewMainMenu:
 		m.MainMenu, cmd = m.MainMenu.Update(msg)
 		cmds = append(cmds, cmd)
+	case ViewSelectProfile:
+		m.ProfileList, cmd = m.ProfileList.Update(msg)
+		cmds = append(cmds, cmd)
 	case ViewSelectPodcast:
 		m.PodcastTable, cmd = m.PodcastTable.Update(msg)
 		cmds = append(cmds, cmd)
 	case ViewEnterURL:
-		m.UrlInput, cmd = m.UrlInput.Update(msg)
+		if m.BatchMode {
+			m.BatchInput, cmd = m.BatchInput.Update(msg)
+		} else {
+			m.UrlInput, cmd = m.UrlInput.Update(msg)
+		}
 		cmds = append(cmds, cmd)
 	case ViewItemsTable:
 		m.ItemsTable, cmd = m.ItemsTable.Update(msg)
 		cmds = append(cmds, cmd)
+	case ViewHistory:
+		m.HistoryTable, cmd = m.HistoryTable.Update(msg)
+		cmds = append(cmds, cmd)
 	}
 
 	m.Spinner, cmd = m.Spinner.Update(msg)
 	cmds = append(cmds, cmd)
 
-	if m.State == ViewItemsTable && len(m.Items) > 0 {
+	if m.State == ViewItemsTable && (len(m.Items) > 0 || len(m.Queue) > 0) {
 		m.buildItemsTable()
 	}
 
@@ -383,7 +736,11 @@ func (m Model) View() string {
 		s.WriteString(HelpStyle.Render("Press any key to exit"))
 
 	case ViewSetAPIKey:
-		s.WriteString(TitleStyle.Render("Set API Key"))
+		title := "Set API Key"
+		if len(m.ProfileNames) > 1 {
+			title += " for profile " + m.Profile.Name
+		}
+		s.WriteString(TitleStyle.Render(title))
 		s.WriteString("\n")
 		if m.Message != "" {
 			s.WriteString(SuccessStyle.Render(m.Message))
@@ -391,11 +748,15 @@ func (m Model) View() string {
 		}
 		s.WriteString(m.ApiKeyInput.View())
 		s.WriteString("\n")
+		if m.Verifying {
+			s.WriteString(m.Spinner.View() + " Verifying API key...")
+			s.WriteString("\n")
+		}
 		if m.Error != "" {
 			s.WriteString(ErrorStyle.Render("Error: " + m.Error))
 			s.WriteString("\n")
 		}
-		s.WriteString(HelpStyle.Render("Press Enter to save • Ctrl+d to clear API key • Esc to cancel"))
+		s.WriteString(HelpStyle.Render("Press Enter to verify and save • Ctrl+d to clear API key • Esc to cancel"))
 
 	case ViewMainMenu:
 		if m.Message != "" {
@@ -404,6 +765,14 @@ func (m Model) View() string {
 		}
 		s.WriteString(m.MainMenu.View())
 		s.WriteString("\n")
+		if len(m.ProfileNames) > 1 {
+			s.WriteString(HelpStyle.Render("Profile: " + m.Profile.Name))
+			s.WriteString("\n")
+		}
+		if m.Queued > 0 {
+			s.WriteString(HelpStyle.Render(fmt.Sprintf("%d URL(s) queued, they will be sent when the API can be reached", m.Queued)))
+			s.WriteString("\n")
+		}
 
 		if m.Usage != nil {
 			s.WriteString("\n")
@@ -411,10 +780,7 @@ func (m Model) View() string {
 			if m.Usage.Limit > 0 {
 				usagePercent = float64(m.Usage.Usage) / float64(m.Usage.Limit)
 			}
-			usageText := fmt.Sprintf("Usage: %s / %s",
-				formatBytes(m.Usage.Usage),
-				formatBytes(m.Usage.Limit),
-			)
+			usageText := "Usage: " + m.Usage.String()
 			s.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color("#626262")).Render(usageText))
 			s.WriteString("\n")
 			s.WriteString(m.ProgressBar.ViewAs(usagePercent))
@@ -426,6 +792,15 @@ func (m Model) View() string {
 
 		s.WriteString(HelpStyle.Render("↑/↓: Navigate • Enter: Select • q: Quit"))
 
+	case ViewSelectProfile:
+		s.WriteString(m.ProfileList.View())
+		s.WriteString("\n")
+		if m.Error != "" {
+			s.WriteString(ErrorStyle.Render("Error: " + m.Error))
+			s.WriteString("\n")
+		}
+		s.WriteString(HelpStyle.Render("↑/↓: Navigate • Enter: Switch • Esc: Back • q: Quit"))
+
 	case ViewSelectPodcast:
 		s.WriteString(TitleStyle.Render("Select a Podcast"))
 		s.WriteString("\n")
@@ -444,19 +819,65 @@ func (m Model) View() string {
 	case ViewEnterURL:
 		s.WriteString(TitleStyle.Render(fmt.Sprintf("Add URL to: %s", m.SelectedPodcast.Title)))
 		s.WriteString("\n")
-		s.WriteString(m.UrlInput.View())
-		s.WriteString("\n")
+		if m.BatchMode {
+			s.WriteString(m.BatchInput.View())
+			s.WriteString("\n")
+			for _, line := range parseBatch(m.BatchInput.Value()) {
+				if line.Err != nil {
+					s.WriteString(ErrorStyle.Render(fmt.Sprintf("✗ %3d  %s — %s", line.Line, line.URL, line.Err)))
+				} else {
+					s.WriteString(SuccessStyle.Render(fmt.Sprintf("✓ %3d  %s", line.Line, line.URL)))
+				}
+				s.WriteString("\n")
+			}
+		} else {
+			s.WriteString(m.UrlInput.View())
+			s.WriteString("\n")
+		}
+		if m.Message != "" {
+			s.WriteString(SuccessStyle.Render(m.Message))
+			s.WriteString("\n")
+		}
 		if m.Error != "" {
 			s.WriteString(ErrorStyle.Render("Error: " + m.Error))
 			s.WriteString("\n")
 		}
-		s.WriteString(HelpStyle.Render("Press Enter to add URL • Esc: Back • q: Quit"))
+		if m.BatchMode {
+			s.WriteString(HelpStyle.Render("Ctrl+s: Submit all • Tab: Single URL • Esc: Back • Ctrl+c: Quit"))
+		} else {
+			s.WriteString(HelpStyle.Render("Press Enter to add URL • Tab: Batch entry • Esc: Back • q: Quit"))
+		}
+
+	case ViewConfirmDuplicates:
+		s.WriteString(TitleStyle.Render(fmt.Sprintf("Possible duplicates in: %s", m.SelectedPodcast.Title)))
+		s.WriteString("\n")
+		for i, u := range m.Pending.URLs {
+			if reason := m.Pending.Duplicates[i]; reason != "" {
+				s.WriteString(ErrorStyle.Render(fmt.Sprintf("! %s — %s", u, reason)))
+			} else {
+				s.WriteString(fmt.Sprintf("  %s", u))
+			}
+			s.WriteString("\n")
+		}
+		s.WriteString(HelpStyle.Render("y: Submit anyway • s: Skip duplicates • Esc: Back • Ctrl+c: Quit"))
 
 	case ViewItemsTable:
 		s.WriteString(TitleStyle.Render(fmt.Sprintf("Items for: %s", m.SelectedPodcast.Title)))
 		s.WriteString("\n")
 		s.WriteString(m.ItemsTable.View())
 		s.WriteString("\n")
+		if m.Message != "" {
+			s.WriteString(SuccessStyle.Render(m.Message))
+			s.WriteString("\n")
+		}
+		if len(m.Submissions) > 0 {
+			s.WriteString(m.submissionSummary())
+			s.WriteString("\n")
+			for _, sub := range m.failedSubmissions() {
+				s.WriteString(ErrorStyle.Render(fmt.Sprintf("✗ %s — %v", sub.URL, sub.Err)))
+				s.WriteString("\n")
+			}
+		}
 		if m.Error != "" {
 			s.WriteString(ErrorStyle.Render("Error: " + m.Error))
 			s.WriteString("\n")
@@ -466,6 +887,38 @@ func (m Model) View() string {
 		} else {
 			s.WriteString(HelpStyle.Render("a: Add another URL • m: Main menu • q: Quit"))
 		}
+
+	case ViewHistory:
+		title := "History"
+		if m.HistoryStatus != "" {
+			title += " (" + strings.ToLower(m.HistoryStatus) + ")"
+		}
+		s.WriteString(TitleStyle.Render(title))
+		s.WriteString("\n")
+		if len(m.History) == 0 {
+			s.WriteString("No submissions found.\n")
+		} else {
+			s.WriteString(m.HistoryTable.View())
+			s.WriteString("\n")
+			if r := m.selectedRecord(); r != nil {
+				s.WriteString(HelpStyle.Render(r.URL))
+				s.WriteString("\n")
+				if errText := firstNonEmpty(r.Error, r.Item.Error); errText != "" {
+					s.WriteString(ErrorStyle.Render(errText))
+					s.WriteString("\n")
+				}
+			}
+		}
+		if m.Error != "" {
+			s.WriteString(ErrorStyle.Render("Error: " + m.Error))
+			s.WriteString("\n")
+		}
+		s.WriteString(HelpStyle.Render("↑/↓: Navigate • s: Filter by status • Esc: Back • q: Quit"))
+	}
+
+	if m.UpdateNotice != "" && m.State != ViewFatalError {
+		s.WriteString("\n")
+		s.WriteString(BannerStyle.Render(m.UpdateNotice))
 	}
 
 	return s.String()
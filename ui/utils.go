@@ -1,89 +1,227 @@
 package ui
 
 import (
-	"fmt"
-	"sort"
+	"context"
+	"slices"
 	"time"
 
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/lsherman98/ytrss-cli/api"
+	"github.com/lsherman98/ytrss-cli/history"
+	"github.com/lsherman98/ytrss-cli/youtube"
 )
 
-func CheckAPIKey() tea.Msg {
-	_, err := api.GetApiKey()
-	return ApiKeyCheckedMsg{HasKey: err == nil}
+const (
+	updateCheckTimeout = 5 * time.Minute
+	queueFlushTimeout  = 5 * time.Minute
+	queueFlushInterval = time.Minute
+)
+
+func CheckAPIKey(ctx context.Context, creds api.CredentialProvider) tea.Cmd {
+	return func() tea.Msg {
+		key, err := creds.APIKey(ctx)
+		return ApiKeyCheckedMsg{HasKey: err == nil && key != ""}
+	}
 }
 
-func LoadPodcasts() tea.Msg {
-	podcasts, err := api.ListPodcasts()
-	return PodcastsLoadedMsg{Podcasts: podcasts, Err: err}
+// LoadPodcasts lists the podcasts, falling back to those profile submitted
+// to when the API cannot be reached so that URLs can still be queued.
+func LoadPodcasts(ctx context.Context, svc api.Service, profile string) tea.Cmd {
+	return func() tea.Msg {
+		podcasts, err := svc.ListPodcasts(ctx)
+		if !api.Temporary(err) {
+			return PodcastsLoadedMsg{Podcasts: podcasts, Err: err}
+		}
+
+		store, storeErr := history.OpenDefault()
+		if storeErr != nil {
+			return PodcastsLoadedMsg{Err: err}
+		}
+		defer store.Close()
+		known, storeErr := store.Podcasts(profile)
+		if storeErr != nil || len(known) == 0 {
+			return PodcastsLoadedMsg{Err: err}
+		}
+		return PodcastsLoadedMsg{Podcasts: known, Offline: true}
+	}
 }
 
-func AddURL(podcastID, url string) tea.Cmd {
+// AddURL submits url, queueing it when the API cannot be reached.
+func AddURL(ctx context.Context, svc api.Service, profile string, podcast api.Podcast, url string) tea.Cmd {
 	return func() tea.Msg {
-		item, err := api.AddUrlToPodcast(podcastID, url)
-		return UrlAddedMsg{Item: item, Err: err}
+		item, err := svc.AddUrlToPodcast(ctx, podcast.ID, url)
+		if canceled(err) {
+			return UrlAddedMsg{URL: url, Err: err}
+		}
+		videoID, idErr := youtube.VideoID(url)
+		if idErr != nil {
+			return UrlAddedMsg{URL: url, Item: item, Err: err}
+		}
+
+		// The history is best effort, so failing to record is ignored.
+		s := history.Submission{URL: url, VideoID: videoID, Podcast: podcast, Profile: profile}
+		if api.Temporary(err) && history.QueueSubmission(s, err) == nil {
+			return UrlAddedMsg{URL: url, Err: err, Queued: true}
+		}
+		history.RecordSubmission(s, item, err)
+		return UrlAddedMsg{URL: url, Item: item, Err: err}
 	}
 }
 
-func LoadItems(podcastID string) tea.Cmd {
+// LoadHistory reads the submissions of profile, newest first, optionally
+// only those with status.
+func LoadHistory(profile, status string) tea.Cmd {
 	return func() tea.Msg {
-		items, err := api.GetPodcastItems(podcastID)
-		return ItemsLoadedMsg{Items: items, Err: err}
+		store, err := history.OpenDefault()
+		if err != nil {
+			return HistoryLoadedMsg{Err: err}
+		}
+		defer store.Close()
+		records, err := store.Query(history.Filter{Profile: profile, Status: status})
+		return HistoryLoadedMsg{Records: records, Err: err}
 	}
 }
 
-func LoadUsage() tea.Cmd {
+// CheckDuplicates compares urls against the podcast's items and the local
+// submission history.
+func CheckDuplicates(ctx context.Context, svc api.Service, podcastID string, urls []string, batch bool) tea.Cmd {
 	return func() tea.Msg {
-		usage, err := api.GetUsage()
-		return UsageLoadedMsg{Usage: usage, Err: err}
+		msg := DuplicatesCheckedMsg{URLs: urls, Batch: batch}
+
+		// Without a connection only the history is checked, so that the
+		// URLs can still be queued.
+		items, err := svc.GetPodcastItems(ctx, podcastID)
+		if err != nil && !api.Temporary(err) {
+			msg.Err = err
+			return msg
+		}
+
+		store, err := history.OpenDefault()
+		if err == nil {
+			defer store.Close()
+		}
+
+		candidates := make([]history.Candidate, len(urls))
+		for i, u := range urls {
+			candidates[i].VideoID, _ = youtube.VideoID(u)
+		}
+
+		msg.Duplicates, msg.Err = history.CheckAll(store, podcastID, items, candidates)
+		return msg
 	}
 }
 
-func parseCreatedTime(created string) time.Time {
-	if created == "" {
-		return time.Time{}
+// LoadItems polls the podcast's items once through w, right away when
+// immediate is set and otherwise after w's interval.
+func LoadItems(ctx context.Context, w api.Waiter, profile string, immediate bool) tea.Cmd {
+	return func() tea.Msg {
+		items, err := w.Poll(ctx, immediate)
+		if err == nil {
+			history.UpdateDefault(w.PodcastID, items)
+		}
+		return ItemsLoadedMsg{Items: items, Queue: loadQueue(profile, w.PodcastID), Err: err}
 	}
+}
 
-	layouts := []string{
-		time.RFC3339Nano,
-		time.RFC3339,
-		"2006-01-02 15:04:05.999Z",
-		"2006-01-02 15:04:05Z",
-		"2006-01-02 15:04:05.999Z07:00",
+// pollItems loads the selected podcast's items, waiting for the poll
+// interval first unless immediate is set.
+func (m Model) pollItems(immediate bool) tea.Cmd {
+	w := api.Waiter{Service: m.Profile.Service, PodcastID: m.SelectedPodcast.ID, Interval: m.PollInterval}
+	return LoadItems(m.ctx, w, m.Profile.Name, immediate)
+}
+
+// loadQueue returns the URLs of profile queued for a podcast, ignoring
+// failures.
+func loadQueue(profile, podcastID string) []history.QueuedURL {
+	store, err := history.OpenDefault()
+	if err != nil {
+		return nil
 	}
-	for _, layout := range layouts {
-		if t, err := time.Parse(layout, created); err == nil {
-			return t
+	defer store.Close()
+
+	queue, _ := store.Queue(profile)
+	var queued []history.QueuedURL
+	for _, q := range queue {
+		if q.PodcastID == podcastID {
+			queued = append(queued, q)
 		}
 	}
+	return queued
+}
 
-	return time.Time{}
+// FlushQueue sends the URLs queued for profile, only those due unless
+// force is set. It runs outside of any view, like CheckForUpdate.
+func FlushQueue(svc api.Service, profile string, force bool) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), queueFlushTimeout)
+		defer cancel()
+
+		msg := QueueFlushedMsg{Profile: profile}
+		path, err := history.DefaultPath()
+		if err != nil {
+			msg.Err = err
+			return msg
+		}
+		msg.Result, msg.Err = history.Flush(ctx, path, svc, profile, force)
+		if msg.Err != nil {
+			return msg
+		}
+
+		// Count again, as URLs may have been queued during the flush.
+		store, err := history.Open(path)
+		if err != nil {
+			msg.Err = err
+			return msg
+		}
+		defer store.Close()
+		queue, err := store.Queue(profile)
+		msg.Queued, msg.Err = len(queue), err
+		return msg
+	}
 }
 
-func tick() tea.Cmd {
-	return tea.Tick(time.Second*3, func(t time.Time) tea.Msg {
-		return TickMsg(t)
-	})
+// flushQueue sends every queued URL right away, for use once a request
+// succeeded. It does nothing when nothing is queued or a flush is already
+// running.
+func (m *Model) flushQueue() tea.Cmd {
+	if m.Queued == 0 || m.flushing {
+		return nil
+	}
+	m.flushing = true
+	return FlushQueue(m.Profile.Service, m.Profile.Name, true)
 }
 
-func formatBytes(bytes int) string {
-	const (
-		KB = 1024
-		MB = 1024 * KB
-		GB = 1024 * MB
-	)
+func VerifyAPIKey(ctx context.Context, svc api.Service, key string) tea.Cmd {
+	return func() tea.Msg {
+		usage, err := svc.VerifyAPIKey(ctx, key)
+		return ApiKeyVerifiedMsg{Key: key, Usage: usage, Err: err}
+	}
+}
+
+func LoadUsage(ctx context.Context, svc api.Service) tea.Cmd {
+	return func() tea.Msg {
+		usage, err := svc.GetUsage(ctx)
+		return UsageLoadedMsg{Usage: usage, Err: err}
+	}
+}
 
-	if bytes >= GB {
-		return fmt.Sprintf("%.2f GB", float64(bytes)/float64(GB))
-	} else if bytes >= MB {
-		return fmt.Sprintf("%.2f MB", float64(bytes)/float64(MB))
-	} else if bytes >= KB {
-		return fmt.Sprintf("%.2f KB", float64(bytes)/float64(KB))
+// CheckForUpdate runs check outside of any view, so that leaving a view
+// does not cancel a download in progress.
+func CheckForUpdate(check func(ctx context.Context) (string, error)) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), updateCheckTimeout)
+		defer cancel()
+		notice, err := check(ctx)
+		return UpdateCheckedMsg{Notice: notice, Err: err}
 	}
-	return fmt.Sprintf("%d B", bytes)
+}
+
+func queueTick() tea.Cmd {
+	return tea.Tick(queueFlushInterval, func(t time.Time) tea.Msg {
+		return QueueTickMsg(t)
+	})
 }
 
 func min(a, b int) int {
@@ -95,45 +233,29 @@ func min(a, b int) int {
 
 func (m *Model) buildItemsTable() {
 	columns := []table.Column{
+		{Title: "", Width: 1},
 		{Title: "Title", Width: 60},
 		{Title: "Status", Width: 20},
 		{Title: "Created", Width: 30},
 	}
 
-	sortedItems := make([]api.Item, len(m.Items))
-	copy(sortedItems, m.Items)
-	sort.Slice(sortedItems, func(i, j int) bool {
-		timeI := parseCreatedTime(sortedItems[i].Created)
-		timeJ := parseCreatedTime(sortedItems[j].Created)
-
-		if timeI.IsZero() && timeJ.IsZero() {
-			return false
-		}
-		if timeI.IsZero() {
-			return false
-		}
-		if timeJ.IsZero() {
-			return true
-		}
-
-		return timeI.After(timeJ)
-	})
+	sortedItems := api.SortItemsByCreated(m.Items)
 
 	rows := []table.Row{}
 	for _, item := range sortedItems {
 		status := item.Status
 		switch item.Status {
-		case "CREATED":
+		case api.StatusCreated:
 			status = m.Spinner.View() + " PROCESSING"
-		case "ERROR":
+		case api.StatusError:
 			status = "❌ ERROR"
-		case "SUCCESS":
+		case api.StatusSuccess:
 			status = "✓ SUCCESS"
 		}
 
 		title := item.Title
 		if title == "" {
-			if item.Status == "CREATED" {
+			if item.Status == api.StatusCreated {
 				title = "Processing..."
 			} else {
 				title = "(No title)"
@@ -142,7 +264,7 @@ func (m *Model) buildItemsTable() {
 
 		created := item.Created
 		if created != "" {
-			t := parseCreatedTime(created)
+			t := api.ParseCreatedTime(created)
 			if !t.IsZero() {
 				created = t.Local().Format("Jan 2, 2006 3:04 PM")
 			}
@@ -150,7 +272,15 @@ func (m *Model) buildItemsTable() {
 			created = "-"
 		}
 
-		rows = append(rows, table.Row{title, status, created})
+		mark := ""
+		if m.isTracked(item) {
+			mark = "●"
+		}
+
+		rows = append(rows, table.Row{mark, title, status, created})
+	}
+	for _, q := range m.Queue {
+		rows = append(rows, table.Row{"", q.URL, "⏸ QUEUED", q.Queued.Local().Format("Jan 2, 2006 3:04 PM")})
 	}
 
 	t := table.New(
@@ -202,3 +332,82 @@ func (m *Model) buildPodcastTable() {
 	t.SetStyles(s)
 	m.PodcastTable = t
 }
+
+func (m *Model) buildHistoryTable() {
+	columns := []table.Column{
+		{Title: "Submitted", Width: 20},
+		{Title: "Status", Width: 12},
+		{Title: "Podcast", Width: 24},
+		{Title: "Title", Width: 50},
+	}
+
+	rows := []table.Row{}
+	for _, r := range m.History {
+		status := r.Status()
+		switch status {
+		case api.StatusCreated:
+			status = "PROCESSING"
+		case api.StatusError:
+			status = "❌ ERROR"
+		case api.StatusSuccess:
+			status = "✓ SUCCESS"
+		case history.StatusFailed:
+			status = "✗ FAILED"
+		}
+
+		podcast := r.PodcastTitle
+		if podcast == "" {
+			podcast = r.PodcastID
+		}
+		title := r.Item.Title
+		if title == "" {
+			title = r.URL
+		}
+
+		rows = append(rows, table.Row{r.Submitted.Local().Format("Jan 2, 2006 3:04 PM"), status, podcast, title})
+	}
+
+	t := table.New(
+		table.WithColumns(columns),
+		table.WithRows(rows),
+		table.WithFocused(true),
+		table.WithHeight(min(len(rows)+2, 20)),
+	)
+
+	s := table.DefaultStyles()
+	s.Header = s.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color("#7D56F4")).
+		BorderBottom(true).
+		Bold(true)
+
+	t.SetStyles(s)
+	m.HistoryTable = t
+}
+
+// selectedRecord returns the history record under the cursor.
+func (m Model) selectedRecord() *history.Record {
+	if i := m.HistoryTable.Cursor(); i >= 0 && i < len(m.History) {
+		return &m.History[i]
+	}
+	return nil
+}
+
+// nextStatus cycles through the history filters, starting and ending with
+// every status.
+func nextStatus(status string) string {
+	i := slices.Index(history.Statuses, status)
+	if i+1 == len(history.Statuses) {
+		return ""
+	}
+	return history.Statuses[i+1]
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
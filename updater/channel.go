@@ -0,0 +1,63 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+	selfupdate "github.com/creativeprojects/go-selfupdate"
+)
+
+// Channel selects which releases are considered.
+type Channel string
+
+const (
+	// ChannelStable only follows regular releases.
+	ChannelStable Channel = "stable"
+	// ChannelBeta also follows releases marked as prereleases.
+	ChannelBeta Channel = "beta"
+)
+
+// parsePin parses a semver constraint such as "~1.4" or "<2". Prereleases
+// satisfy it only on the beta channel.
+func parsePin(pin string, channel Channel) (*semver.Constraints, error) {
+	if pin == "" {
+		return nil, nil
+	}
+	c, err := semver.NewConstraint(pin)
+	if err != nil {
+		return nil, fmt.Errorf("update pin %q: %w", pin, err)
+	}
+	c.IncludePrerelease = channel == ChannelBeta
+	return c, nil
+}
+
+// pinnedSource hides the releases of a Source that do not satisfy a pin,
+// so that the latest release found is the latest one allowed.
+type pinnedSource struct {
+	selfupdate.Source
+	pin *semver.Constraints
+}
+
+func (s pinnedSource) ListReleases(ctx context.Context, repository selfupdate.Repository) ([]selfupdate.SourceRelease, error) {
+	releases, err := s.Source.ListReleases(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	allowed := releases[:0]
+	for _, release := range releases {
+		if v, err := parseTag(release.GetTagName()); err == nil && s.pin.Check(v) {
+			allowed = append(allowed, release)
+		}
+	}
+	return allowed, nil
+}
+
+// parseTag parses a release tag, skipping any prefix before the version
+// the same way the release lookup does.
+func parseTag(tag string) (*semver.Version, error) {
+	if i := versionPattern.FindStringIndex(tag); i != nil {
+		tag = tag[i[0]:]
+	}
+	return semver.NewVersion(tag)
+}
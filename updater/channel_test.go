@@ -0,0 +1,139 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func prerelease(t *testing.T, version string) fakeRelease {
+	t.Helper()
+	rel := newRelease(t, version, "v"+version)
+	rel.Prerelease = true
+	return rel
+}
+
+func TestPinFiltersNewerReleases(t *testing.T) {
+	fakeExecutable(t)
+	srv := releaseServer(t, newRelease(t, "1.2.0", "v1.2.0"), newRelease(t, "1.2.3", "v1.2.3"), newRelease(t, "1.3.0", "v1.3.0"), newRelease(t, "2.0.0", "v2.0.0"))
+
+	tests := []struct {
+		pin  string
+		want string
+	}{
+		{"", "2.0.0"},
+		{"~1.2", "1.2.3"},
+		{"^1", "1.3.0"},
+		{"<1.3", "1.2.3"},
+	}
+	for _, tt := range tests {
+		u := newTestUpdater(t, srv, Options{Pin: tt.pin})
+		latest, newer, err := u.CheckForUpdate(context.Background(), "1.2.0")
+		if err != nil {
+			t.Fatalf("pin %q: %v", tt.pin, err)
+		}
+		if latest.Version() != tt.want || !newer {
+			t.Errorf("pin %q: latest = %s (newer %t), want %s", tt.pin, latest.Version(), newer, tt.want)
+		}
+	}
+
+	u := newTestUpdater(t, srv, Options{Pin: "~3"})
+	if _, _, err := u.CheckForUpdate(context.Background(), "1.2.0"); err == nil || !strings.Contains(err.Error(), "no releases found matching pin") {
+		t.Errorf("CheckForUpdate with nothing matching the pin = %v", err)
+	}
+}
+
+func TestChannels(t *testing.T) {
+	fakeExecutable(t)
+	srv := releaseServer(t, newRelease(t, "1.1.0", "v1.1.0"), prerelease(t, "1.2.0-beta.1"))
+
+	tests := []struct {
+		channel Channel
+		pin     string
+		want    string
+	}{
+		{ChannelStable, "", "1.1.0"},
+		{"", "", "1.1.0"},
+		{ChannelBeta, "", "1.2.0-beta.1"},
+		{ChannelBeta, "^1", "1.2.0-beta.1"},
+		// On the stable channel a pin does not admit prereleases either.
+		{ChannelStable, "^1", "1.1.0"},
+	}
+	for _, tt := range tests {
+		u := newTestUpdater(t, srv, Options{Channel: tt.channel, Pin: tt.pin})
+		latest, _, err := u.CheckForUpdate(context.Background(), "1.0.0")
+		if err != nil {
+			t.Fatalf("channel %q, pin %q: %v", tt.channel, tt.pin, err)
+		}
+		if latest.Version() != tt.want {
+			t.Errorf("channel %q, pin %q: latest = %s, want %s", tt.channel, tt.pin, latest.Version(), tt.want)
+		}
+	}
+}
+
+func TestInvalidPin(t *testing.T) {
+	for _, pin := range []string{"latest", "~1.x.y.z", ">>1"} {
+		if _, err := New(Options{Pin: pin}); err == nil || !strings.Contains(err.Error(), "update pin") {
+			t.Errorf("New with pin %q = %v, want the pin rejected", pin, err)
+		}
+	}
+}
+
+func TestRollback(t *testing.T) {
+	exe := fakeExecutable(t)
+	srv := releaseServer(t, newRelease(t, "1.1.0", "v1.1.0"))
+	u := newTestUpdater(t, srv, Options{})
+	if _, err := u.Check(context.Background(), "1.0.0", ModeAuto); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := Rollback("1.1.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored != "1.0.0" {
+		t.Errorf("Rollback restored %q, want 1.0.0", restored)
+	}
+	if got := readFile(t, exe); got != "old" {
+		t.Errorf("executable = %q, want the previous version", got)
+	}
+	if got := readFile(t, backupPath(exe)); got != "v1.1.0" {
+		t.Errorf("backup = %q, want the version rolled back from", got)
+	}
+
+	// The version rolled back from is reported but not installed again.
+	state := LoadState()
+	if state.RolledBack != "1.1.0" {
+		t.Errorf("RolledBack = %q, want 1.1.0", state.RolledBack)
+	}
+	state.LastCheck = state.LastCheck.AddDate(0, 0, -2)
+	SaveState(state)
+	msg, err := u.Check(context.Background(), "1.0.0", ModeAuto)
+	if err != nil || !strings.HasPrefix(msg, "Version 1.1.0 is available") {
+		t.Errorf("Check after rollback = %q, %v, want 1.1.0 only reported", msg, err)
+	}
+	if got := readFile(t, exe); got != "old" {
+		t.Errorf("executable = %q, want the rollback kept", got)
+	}
+
+	// Rolling back again returns to the newer version.
+	restored, err = Rollback("1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored != "1.1.0" || readFile(t, exe) != "v1.1.0" {
+		t.Errorf("second Rollback restored %q, executable %q", restored, readFile(t, exe))
+	}
+}
+
+func TestRollbackWithoutBackup(t *testing.T) {
+	exe := fakeExecutable(t)
+
+	if _, err := Rollback("1.0.0"); !errors.Is(err, ErrNoBackup) {
+		t.Errorf("Rollback = %v, want ErrNoBackup", err)
+	}
+	if got := readFile(t, exe); got != "old" {
+		t.Errorf("executable = %q, want it left alone", got)
+	}
+}
@@ -0,0 +1,153 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/lsherman98/ytrss-cli/xdg"
+)
+
+// Mode controls what the background update check does.
+type Mode string
+
+const (
+	// ModeAuto installs new releases; they are used from the next start.
+	ModeAuto Mode = "auto"
+	// ModeNotify only reports new releases.
+	ModeNotify Mode = "notify"
+	// ModeOff disables the check.
+	ModeOff Mode = "off"
+)
+
+const stateFileName = "update.json"
+
+// State is what the update check remembers between runs.
+type State struct {
+	LastCheck     time.Time `json:"last_check"`
+	LatestVersion string    `json:"latest_version,omitempty"`
+	// PreviousVersion is the version of the executable kept by the last
+	// update, which Rollback restores.
+	PreviousVersion string `json:"previous_version,omitempty"`
+	// RolledBack is a version that was rolled back. It is reported but not
+	// installed automatically.
+	RolledBack string `json:"rolled_back,omitempty"`
+}
+
+func statePath() (string, error) {
+	dir, err := xdg.StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, stateFileName), nil
+}
+
+// LoadState reads the persisted state. A missing or unreadable file yields
+// the zero state, so that the next check runs.
+func LoadState() State {
+	var state State
+	path, err := statePath()
+	if err != nil {
+		return state
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return state
+	}
+	json.Unmarshal(data, &state)
+	return state
+}
+
+func SaveState(state State) error {
+	path, err := statePath()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// newer reports whether version is a newer release than current. A
+// current version that does not parse, such as a dev build, is older than
+// any release.
+func newer(version, current string) bool {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return false
+	}
+	c, err := semver.NewVersion(current)
+	if err != nil {
+		return true
+	}
+	return v.GreaterThan(c)
+}
+
+// Check looks for a new release at most once a day and, in ModeAuto,
+// installs it unless a package manager owns the executable. It returns a
+// short message for the user, or "" when there is nothing to report.
+// Between checks the release found last time is reported again.
+func (u *Updater) Check(ctx context.Context, currentVersion string, mode Mode) (string, error) {
+	if mode == ModeOff || currentVersion == "dev" {
+		return "", nil
+	}
+
+	// Without a known install, updating fails with the reason later.
+	install, _ := DetectInstall()
+
+	state := LoadState()
+	if !ShouldCheckForUpdate(state.LastCheck) {
+		if newer(state.LatestVersion, currentVersion) {
+			return available(state.LatestVersion, currentVersion, install), nil
+		}
+		return "", nil
+	}
+
+	latest, found, err := u.up.DetectLatest(ctx, u.repository)
+	if err != nil {
+		// Failed checks count too, so that being offline does not mean a
+		// request on every start.
+		state.LastCheck = time.Now()
+		SaveState(state)
+		return "", fmt.Errorf("error checking for updates: %w", verificationError(err))
+	}
+
+	state.LastCheck = time.Now()
+	state.LatestVersion = ""
+	if found {
+		state.LatestVersion = latest.Version()
+	}
+	if err := SaveState(state); err != nil && !errors.Is(err, fs.ErrPermission) {
+		return "", err
+	}
+
+	if !found || !newer(latest.Version(), currentVersion) {
+		return "", nil
+	}
+	if mode != ModeAuto || latest.Version() == state.RolledBack || !install.SelfUpdatable() {
+		return available(latest.Version(), currentVersion, install), nil
+	}
+
+	if err := u.DoSelfUpdate(ctx, latest, currentVersion); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Updated to version %s, restart ytrss to use it", latest.Version()), nil
+}
+
+func available(latest, current string, install Install) string {
+	if !install.SelfUpdatable() {
+		return fmt.Sprintf("Version %s is available (current: %s), %s", latest, current, install.Advice())
+	}
+	return fmt.Sprintf("Version %s is available (current: %s), run ytrss update to install it", latest, current)
+}
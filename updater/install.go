@@ -0,0 +1,88 @@
+package updater
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	selfupdate "github.com/creativeprojects/go-selfupdate"
+)
+
+// Install describes where the running executable lives and whether ytrss
+// may replace it.
+type Install struct {
+	Path string
+	// Manager is the package manager that owns the executable, e.g.
+	// Homebrew, or "" for a manual install.
+	Manager string
+	// Command updates ytrss through Manager.
+	Command string
+	// ReadOnly is set when the executable cannot be replaced because its
+	// directory is not writable.
+	ReadOnly bool
+}
+
+// executablePath locates the running executable. Tests point it at a
+// stand-in so that updates do not replace the test binary.
+var executablePath = selfupdate.ExecutablePath
+
+// DetectInstall inspects the running executable.
+func DetectInstall() (Install, error) {
+	exe, err := executablePath()
+	if err != nil {
+		return Install{}, fmt.Errorf("could not locate executable path: %w", err)
+	}
+	return detectInstall(exe), nil
+}
+
+// detectInstall works on the executable path with symlinks resolved, so
+// that e.g. /opt/homebrew/bin/ytrss is seen in the Cellar it links to.
+func detectInstall(exe string) Install {
+	install := Install{Path: exe}
+	slashed := filepath.ToSlash(exe)
+	switch {
+	case strings.Contains(slashed, "/Cellar/"):
+		install.Manager = "Homebrew"
+		install.Command = "brew upgrade ytrss"
+	case strings.HasPrefix(slashed, "/nix/store/"):
+		install.Manager = "Nix"
+		install.Command = "nix profile upgrade ytrss"
+	default:
+		install.ReadOnly = !writable(filepath.Dir(exe))
+	}
+	return install
+}
+
+// writable reports whether files can be created in dir, which replacing
+// the executable requires.
+func writable(dir string) bool {
+	f, err := os.CreateTemp(dir, ".ytrss-write-test-*")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return true
+}
+
+// SelfUpdatable reports whether ytrss may replace the executable itself.
+// Package managers keep track of the files they install, so their
+// installs are updated through them instead.
+func (i Install) SelfUpdatable() bool {
+	return i.Manager == "" && !i.ReadOnly
+}
+
+// Advice tells the user how to update an install that is not
+// SelfUpdatable.
+func (i Install) Advice() string {
+	switch {
+	case i.Manager == "Nix":
+		return "ytrss was installed with Nix, update it with " + i.Command + " or wherever it is declared in your Nix configuration"
+	case i.Manager != "":
+		return fmt.Sprintf("ytrss was installed with %s, update it with %s", i.Manager, i.Command)
+	case i.ReadOnly:
+		return fmt.Sprintf("ytrss cannot update itself because %s is not writable, update it with the tool that installed it or run sudo ytrss update if you installed it by hand", filepath.Dir(i.Path))
+	}
+	return "run ytrss update"
+}
@@ -0,0 +1,85 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectInstall(t *testing.T) {
+	writableDir := t.TempDir()
+	readOnlyDir := t.TempDir()
+	if err := os.Chmod(readOnlyDir, 0o555); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(readOnlyDir, 0o755) })
+
+	tests := []struct {
+		name       string
+		exe        string
+		readOnly   bool
+		updatable  bool
+		wantAdvice string
+	}{
+		{
+			name:       "Homebrew",
+			exe:        "/opt/homebrew/Cellar/ytrss/1.0.0/bin/ytrss",
+			wantAdvice: "ytrss was installed with Homebrew, update it with brew upgrade ytrss",
+		},
+		{
+			name:       "Linuxbrew",
+			exe:        "/home/linuxbrew/.linuxbrew/Cellar/ytrss/1.0.0/bin/ytrss",
+			wantAdvice: "ytrss was installed with Homebrew, update it with brew upgrade ytrss",
+		},
+		{
+			name:       "Nix",
+			exe:        "/nix/store/0c9qnfm2sv4hbkqmd3pv0k1vqq8x8x0g-ytrss-1.0.0/bin/ytrss",
+			wantAdvice: "ytrss was installed with Nix, update it with nix profile upgrade ytrss or wherever it is declared in your Nix configuration",
+		},
+		{
+			name:       "read-only directory",
+			exe:        filepath.Join(readOnlyDir, "ytrss"),
+			readOnly:   true,
+			wantAdvice: "ytrss cannot update itself because " + readOnlyDir + " is not writable, update it with the tool that installed it or run sudo ytrss update if you installed it by hand",
+		},
+		{
+			name:       "writable directory",
+			exe:        filepath.Join(writableDir, "ytrss"),
+			updatable:  true,
+			wantAdvice: "run ytrss update",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.readOnly && writable(readOnlyDir) {
+				t.Skip("directory permissions are not enforced for this user")
+			}
+
+			install := detectInstall(tt.exe)
+			if install.Path != tt.exe {
+				t.Errorf("Path = %q, want %q", install.Path, tt.exe)
+			}
+			if install.ReadOnly != tt.readOnly {
+				t.Errorf("ReadOnly = %t, want %t", install.ReadOnly, tt.readOnly)
+			}
+			if got := install.SelfUpdatable(); got != tt.updatable {
+				t.Errorf("SelfUpdatable = %t, want %t", got, tt.updatable)
+			}
+			if got := install.Advice(); got != tt.wantAdvice {
+				t.Errorf("Advice = %q, want %q", got, tt.wantAdvice)
+			}
+		})
+	}
+}
+
+func TestReadOnlyInstallAdvice(t *testing.T) {
+	// Checked apart from detection, which root always finds writable.
+	install := Install{Path: "/usr/local/bin/ytrss", ReadOnly: true}
+	if install.SelfUpdatable() {
+		t.Error("read-only install is SelfUpdatable")
+	}
+	want := "ytrss cannot update itself because /usr/local/bin is not writable, update it with the tool that installed it or run sudo ytrss update if you installed it by hand"
+	if got := install.Advice(); got != want {
+		t.Errorf("Advice = %q, want %q", got, want)
+	}
+}
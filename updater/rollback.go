@@ -0,0 +1,57 @@
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ErrNoBackup is returned by Rollback when no update has been installed
+// yet.
+var ErrNoBackup = errors.New("no previous version to roll back to")
+
+// backupPath is where the executable at exe is kept while a newer version
+// replaces it. It has to be in the same directory so that it can be
+// renamed into place.
+func backupPath(exe string) string {
+	return filepath.Join(filepath.Dir(exe), "."+filepath.Base(exe)+".old")
+}
+
+// Rollback swaps the running executable with the one kept by the last
+// update and returns the version restored, or "" if it is not known.
+// Rolling back twice returns to the newer version. The version rolled back
+// from is not installed again by the background check.
+func Rollback(currentVersion string) (string, error) {
+	exe, err := executablePath()
+	if err != nil {
+		return "", fmt.Errorf("could not locate executable path: %w", err)
+	}
+	if install := detectInstall(exe); !install.SelfUpdatable() {
+		return "", errors.New(install.Advice())
+	}
+	backup := backupPath(exe)
+	if _, err := os.Stat(backup); errors.Is(err, fs.ErrNotExist) {
+		return "", ErrNoBackup
+	}
+
+	tmp := backup + ".swap"
+	if err := os.Rename(exe, tmp); err != nil {
+		return "", fmt.Errorf("error rolling back: %w", err)
+	}
+	if err := os.Rename(backup, exe); err != nil {
+		os.Rename(tmp, exe)
+		return "", fmt.Errorf("error rolling back: %w", err)
+	}
+	if err := os.Rename(tmp, backup); err != nil {
+		return "", fmt.Errorf("error keeping version %s as backup: %w", currentVersion, err)
+	}
+
+	state := LoadState()
+	restored := state.PreviousVersion
+	state.PreviousVersion = currentVersion
+	state.RolledBack = currentVersion
+	SaveState(state)
+	return restored, nil
+}
@@ -0,0 +1,79 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	selfupdate "github.com/creativeprojects/go-selfupdate"
+)
+
+// SourceKinds are the kinds of release source NewSource can create.
+var SourceKinds = []string{"github", "gitlab", "gitea", "http"}
+
+// DefaultRepository is where ytrss releases are published.
+const DefaultRepository = repoOwner + "/" + repoName
+
+// SourceConfig describes where releases are published.
+type SourceConfig struct {
+	// Kind is one of SourceKinds, github when empty.
+	Kind string
+	// URL is the base URL of the server. It is required for gitea and
+	// http; for github it selects a GitHub Enterprise API and for gitlab a
+	// self-hosted instance.
+	URL string
+}
+
+// NewSource creates the release source described by cfg. Private
+// repositories are read with the token in $GITHUB_TOKEN, $GITLAB_TOKEN or
+// $GITEA_TOKEN.
+//
+// The http kind reads <URL>/<owner>/<repo>/manifest.yaml, a list of
+// releases whose asset URLs may be relative to the same directory, so a
+// plain static file server can host releases, e.g. for air-gapped
+// installs or tests.
+func NewSource(cfg SourceConfig) (selfupdate.Source, error) {
+	switch cfg.Kind {
+	case "", "github":
+		return selfupdate.NewGitHubSource(selfupdate.GitHubConfig{EnterpriseBaseURL: cfg.URL})
+	case "gitlab":
+		return selfupdate.NewGitLabSource(selfupdate.GitLabConfig{BaseURL: cfg.URL})
+	case "gitea":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("gitea release source needs a URL")
+		}
+		return selfupdate.NewGiteaSource(selfupdate.GiteaConfig{BaseURL: cfg.URL})
+	case "http":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("http release source needs a URL")
+		}
+		source, err := selfupdate.NewHttpSource(selfupdate.HttpConfig{BaseURL: cfg.URL})
+		if err != nil {
+			return nil, err
+		}
+		return httpSource{source}, nil
+	}
+	return nil, fmt.Errorf("unknown release source %q, use one of %s", cfg.Kind, strings.Join(SourceKinds, ", "))
+}
+
+// httpSource lets selfupdate.HttpSource download every file of the
+// validation chain. On its own it only knows the asset and the first
+// validation file, so checksums.txt.sig could never be fetched.
+type httpSource struct {
+	*selfupdate.HttpSource
+}
+
+func (s httpSource) DownloadReleaseAsset(ctx context.Context, rel *selfupdate.Release, assetID int64) (io.ReadCloser, error) {
+	if rel != nil && assetID != rel.AssetID {
+		for _, va := range rel.ValidationChain {
+			if va.ValidationAssetID == assetID {
+				step := *rel
+				step.ValidationAssetID = va.ValidationAssetID
+				step.ValidationAssetURL = va.ValidationAssetURL
+				return s.HttpSource.DownloadReleaseAsset(ctx, &step, assetID)
+			}
+		}
+	}
+	return s.HttpSource.DownloadReleaseAsset(ctx, rel, assetID)
+}
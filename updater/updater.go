@@ -1,76 +1,227 @@
 package updater
 
 import (
+	"cmp"
 	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"regexp"
 	"runtime"
+	"strings"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
 	selfupdate "github.com/creativeprojects/go-selfupdate"
-	"github.com/google/go-github/v57/github"
 )
 
 const (
 	repoOwner = "lsherman98"
 	repoName  = "ytrss-cli"
+
+	// checksumsFile is published with every release by GoReleaser.
+	checksumsFile = "checksums.txt"
 )
 
-func CheckForUpdate(currentVersion string) (*selfupdate.Release, bool, error) {
-	latest, found, err := selfupdate.DetectLatest(context.Background(), selfupdate.ParseSlug(fmt.Sprintf("%s/%s", repoOwner, repoName)))
-	if err != nil {
-		return nil, false, fmt.Errorf("error checking for updates: %w", err)
+var versionPattern = regexp.MustCompile(`\d+\.\d+\.\d+`)
+
+// Options configures an Updater.
+type Options struct {
+	// Source is where releases are looked up, GitHub by default. Tests can
+	// point it at a local fake release server, see NewSource.
+	Source selfupdate.Source
+	// Repository is the owner/name of the project on Source,
+	// DefaultRepository when empty.
+	Repository string
+	// PublicKey is a PEM-encoded ECDSA public key or certificate. When set,
+	// checksums.txt must carry a valid signature in checksums.txt.sig.
+	PublicKey []byte
+	// Channel is ChannelStable when empty.
+	Channel Channel
+	// Pin is a semver constraint that releases must satisfy, e.g. "~1.4".
+	Pin string
+}
+
+// Updater finds and installs releases. Every download is verified against
+// the release's checksums.txt before the executable is replaced.
+type Updater struct {
+	up         *selfupdate.Updater
+	repository selfupdate.Repository
+	pin        *semver.Constraints
+}
+
+func New(opts Options) (*Updater, error) {
+	var validator selfupdate.Validator = &selfupdate.ChecksumValidator{UniqueFilename: checksumsFile}
+	if len(opts.PublicKey) > 0 {
+		key, err := parsePublicKey(opts.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		validator = new(selfupdate.PatternValidator).
+			Add(checksumsFile, &selfupdate.ECDSAValidator{PublicKey: key}).
+			Add("*", validator).
+			SkipValidation("*.sig")
 	}
 
-	if !found {
-		return nil, false, fmt.Errorf("no releases found")
+	pin, err := parsePin(opts.Pin, opts.Channel)
+	if err != nil {
+		return nil, err
+	}
+	source := opts.Source
+	if source == nil {
+		if source, err = NewSource(SourceConfig{}); err != nil {
+			return nil, err
+		}
+	}
+	if pin != nil {
+		source = pinnedSource{Source: source, pin: pin}
 	}
 
-	if latest.LessOrEqual(currentVersion) {
-		return latest, false, nil
+	// The replaced executable is kept for Rollback. Without a path the
+	// update still works, it just cannot be undone.
+	var oldSavePath string
+	if exe, err := executablePath(); err == nil {
+		oldSavePath = backupPath(exe)
 	}
 
-	return latest, true, nil
+	up, err := selfupdate.NewUpdater(selfupdate.Config{
+		Source:      source,
+		Validator:   validator,
+		Prerelease:  opts.Channel == ChannelBeta,
+		OldSavePath: oldSavePath,
+	})
+	if err != nil {
+		return nil, err
+	}
+	repository := cmp.Or(opts.Repository, DefaultRepository)
+	if _, _, err := selfupdate.ParseSlug(repository).GetSlug(); err != nil {
+		return nil, fmt.Errorf("update repository %q: %w", repository, err)
+	}
+	return &Updater{up: up, repository: selfupdate.ParseSlug(repository), pin: pin}, nil
 }
 
-func DoSelfUpdate(currentVersion string) error {
-	latest, found, err := selfupdate.DetectLatest(context.Background(), selfupdate.ParseSlug(fmt.Sprintf("%s/%s", repoOwner, repoName)))
+// parsePublicKey accepts a PEM "PUBLIC KEY" or "CERTIFICATE" block holding
+// an ECDSA key.
+func parsePublicKey(data []byte) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("update public key: no PEM data found")
+	}
+
+	var pub any
+	var err error
+	switch block.Type {
+	case "PUBLIC KEY":
+		pub, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			pub = cert.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("update public key: unexpected PEM block %q", block.Type)
+	}
 	if err != nil {
-		return fmt.Errorf("error checking for updates: %w", err)
+		return nil, fmt.Errorf("update public key: %w", err)
 	}
 
-	if !found {
-		return fmt.Errorf("no releases found")
+	key, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("update public key: not an ECDSA key")
 	}
+	return key, nil
+}
 
-	if latest.LessOrEqual(currentVersion) {
-		fmt.Printf("Current version %s is already the latest\n", currentVersion)
-		return nil
-	}
+// verificationError explains why a release could not be verified.
+func verificationError(err error) error {
+	switch {
+	case errors.Is(err, selfupdate.ErrValidationAssetNotFound):
+		return fmt.Errorf("update aborted, the release cannot be verified: %w", err)
+	case errors.Is(err, selfupdate.ErrChecksumValidationFailed),
+		errors.Is(err, selfupdate.ErrHashNotFound),
+		errors.Is(err, selfupdate.ErrIncorrectChecksumFile):
+		return fmt.Errorf("update aborted, the download does not match %s: %w", checksumsFile, err)
+	case errors.Is(err, selfupdate.ErrECDSAValidationFailed),
+		errors.Is(err, selfupdate.ErrInvalidECDSASignature):
+		return fmt.Errorf("update aborted, %s is not signed with the pinned key: %w", checksumsFile, err)
+	}
+	return err
+}
 
-	exe, err := selfupdate.ExecutablePath()
+// CheckForUpdate returns the latest release and whether it is newer than
+// currentVersion. Development builds are always considered outdated.
+func (u *Updater) CheckForUpdate(ctx context.Context, currentVersion string) (*selfupdate.Release, bool, error) {
+	latest, found, err := u.up.DetectLatest(ctx, u.repository)
 	if err != nil {
-		return fmt.Errorf("could not locate executable path: %w", err)
+		return nil, false, fmt.Errorf("error checking for updates: %w", verificationError(err))
 	}
 
-	if err := selfupdate.UpdateTo(context.Background(), latest.AssetURL, latest.AssetName, exe); err != nil {
-		return fmt.Errorf("error updating binary: %w", err)
+	if !found {
+		if u.pin != nil {
+			return nil, false, fmt.Errorf("no releases found matching pin %s", u.pin)
+		}
+		return nil, false, fmt.Errorf("no releases found")
 	}
 
-	fmt.Printf("Successfully updated to version %s\n", latest.Version())
-	return nil
+	return latest, newer(latest.Version(), currentVersion), nil
+}
+
+// FindRelease returns the release with the given version, with or without
+// a leading "v". Versions outside the pin are refused.
+func (u *Updater) FindRelease(ctx context.Context, version string) (*selfupdate.Release, error) {
+	if u.pin != nil {
+		v, err := semver.NewVersion(version)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version %q: %w", version, err)
+		}
+		if !u.pin.Check(v) {
+			return nil, fmt.Errorf("version %s is outside the pin %s", version, u.pin)
+		}
+	}
+
+	// Release lookups match the tag name exactly.
+	bare := strings.TrimPrefix(version, "v")
+	var release *selfupdate.Release
+	var found bool
+	for _, tag := range []string{"v" + bare, bare} {
+		var err error
+		release, found, err = u.up.DetectVersion(ctx, u.repository, tag)
+		if err != nil {
+			return nil, fmt.Errorf("error looking up version %s: %w", version, verificationError(err))
+		}
+		if found {
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("version %s not found or has no build for %s/%s", version, runtime.GOOS, runtime.GOARCH)
+	}
+	return release, nil
 }
 
-func GetLatestReleaseInfo() (string, string, error) {
-	client := github.NewClient(nil)
-	release, _, err := client.Repositories.GetLatestRelease(context.Background(), repoOwner, repoName)
+// DoSelfUpdate downloads release, verifies it and replaces the running
+// executable with it, unless a package manager owns it. The executable of
+// currentVersion is kept so that the update can be rolled back.
+func (u *Updater) DoSelfUpdate(ctx context.Context, release *selfupdate.Release, currentVersion string) error {
+	exe, err := executablePath()
 	if err != nil {
-		return "", "", err
+		return fmt.Errorf("could not locate executable path: %w", err)
+	}
+	if install := detectInstall(exe); !install.SelfUpdatable() {
+		return errors.New(install.Advice())
 	}
 
-	version := release.GetTagName()
-	notes := release.GetBody()
+	if err := u.up.UpdateTo(ctx, release, exe); err != nil {
+		return fmt.Errorf("error updating binary: %w", verificationError(err))
+	}
 
-	return version, notes, nil
+	state := LoadState()
+	state.PreviousVersion = currentVersion
+	state.RolledBack = ""
+	SaveState(state)
+	return nil
 }
 
 func ShouldCheckForUpdate(lastCheck time.Time) bool {
@@ -88,38 +239,3 @@ func GetAssetName(version string) string {
 
 	return fmt.Sprintf("ytrss-cli_%s_%s_%s%s", version, osName, arch, ext)
 }
-
-func CheckAndUpdate(currentVersion string) (bool, error) {
-	if currentVersion == "dev" {
-		return false, nil
-	}
-
-	latest, found, err := selfupdate.DetectLatest(context.Background(), selfupdate.ParseSlug(fmt.Sprintf("%s/%s", repoOwner, repoName)))
-	if err != nil {
-		return false, nil
-	}
-
-	if !found {
-		return false, nil
-	}
-
-	if latest.LessOrEqual(currentVersion) {
-		return false, nil
-	}
-
-	fmt.Printf("🎉 New version available: %s (current: %s)\n", latest.Version(), currentVersion)
-	fmt.Println("Updating...")
-
-	exe, err := selfupdate.ExecutablePath()
-	if err != nil {
-		return false, fmt.Errorf("could not locate executable path: %w", err)
-	}
-
-	if err := selfupdate.UpdateTo(context.Background(), latest.AssetURL, latest.AssetName, exe); err != nil {
-		return false, fmt.Errorf("update failed: %w", err)
-	}
-
-	fmt.Printf("✅ Successfully updated to version %s!\n", latest.Version())
-	fmt.Println("Please restart the application to use the new version.")
-	return true, nil
-}
@@ -0,0 +1,223 @@
+package updater
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeRelease is a release on releaseServer. Files are its assets by
+// name.
+type fakeRelease struct {
+	Tag        string
+	Files      map[string][]byte
+	Prerelease bool
+}
+
+// newRelease builds a release of version as GoReleaser publishes it: an
+// archive holding an executable with the given content, and checksums.txt
+// listing the archive.
+func newRelease(t *testing.T, version, exe string) fakeRelease {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("release archives are zip files on Windows")
+	}
+
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	tw.WriteHeader(&tar.Header{Name: "ytrss", Mode: 0o755, Size: int64(len(exe))})
+	tw.Write([]byte(exe))
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	name := GetAssetName(version)
+	return fakeRelease{
+		Tag: "v" + version,
+		Files: map[string][]byte{
+			name:          archive.Bytes(),
+			checksumsFile: fmt.Appendf(nil, "%x  %s\n", sha256.Sum256(archive.Bytes()), name),
+		},
+	}
+}
+
+// releaseServer serves releases for the http source: manifest.yaml lists
+// them under /<owner>/<repo>/ and each file is served from <tag>/<name>
+// next to it.
+func releaseServer(t *testing.T, releases ...fakeRelease) *httptest.Server {
+	t.Helper()
+	dir := "/" + DefaultRepository + "/"
+
+	var manifest strings.Builder
+	files := map[string][]byte{}
+	var assetID int
+	manifest.WriteString("releases:\n")
+	for i, rel := range releases {
+		fmt.Fprintf(&manifest, "  - id: %d\n    tag_name: %s\n    name: %s\n    prerelease: %t\n    published_at: 2024-05-01T12:00:00Z\n    assets:\n", i+1, rel.Tag, rel.Tag, rel.Prerelease)
+		for _, name := range slices.Sorted(maps.Keys(rel.Files)) {
+			assetID++
+			fmt.Fprintf(&manifest, "      - id: %d\n        name: %s\n        size: %d\n        url: %s/%s\n", assetID, name, len(rel.Files[name]), rel.Tag, name)
+			files[path.Join(dir, rel.Tag, name)] = rel.Files[name]
+		}
+	}
+	files[dir+"manifest.yaml"] = []byte(manifest.String())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// fakeExecutable stands in for the running executable with content "old"
+// and keeps the update state in a temporary directory.
+func fakeExecutable(t *testing.T) string {
+	t.Helper()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+
+	exe := filepath.Join(t.TempDir(), "ytrss")
+	if err := os.WriteFile(exe, []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	saved := executablePath
+	executablePath = func() (string, error) { return exe, nil }
+	t.Cleanup(func() { executablePath = saved })
+	return exe
+}
+
+func newTestUpdater(t *testing.T, srv *httptest.Server, opts Options) *Updater {
+	t.Helper()
+	source, err := NewSource(SourceConfig{Kind: "http", URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Source = source
+	u, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCheckNotify(t *testing.T) {
+	exe := fakeExecutable(t)
+	srv := releaseServer(t, newRelease(t, "1.0.0", "v1.0.0"), newRelease(t, "1.1.0", "v1.1.0"))
+	u := newTestUpdater(t, srv, Options{})
+
+	msg, err := u.Check(context.Background(), "1.0.0", ModeNotify)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Version 1.1.0 is available (current: 1.0.0), run ytrss update to install it"; msg != want {
+		t.Errorf("Check = %q, want %q", msg, want)
+	}
+	if got := readFile(t, exe); got != "old" {
+		t.Errorf("executable = %q, want it left alone", got)
+	}
+
+	state := LoadState()
+	if state.LatestVersion != "1.1.0" || time.Since(state.LastCheck) > time.Minute {
+		t.Errorf("state = %+v, want the check and 1.1.0 recorded", state)
+	}
+
+	// Until the next check is due the release is reported from the state.
+	srv.Close()
+	msg, err = u.Check(context.Background(), "1.0.0", ModeNotify)
+	if err != nil || !strings.HasPrefix(msg, "Version 1.1.0 is available") {
+		t.Errorf("second Check = %q, %v, want 1.1.0 reported again", msg, err)
+	}
+}
+
+func TestCheckAuto(t *testing.T) {
+	exe := fakeExecutable(t)
+	srv := releaseServer(t, newRelease(t, "1.1.0", "v1.1.0"))
+	u := newTestUpdater(t, srv, Options{})
+
+	msg, err := u.Check(context.Background(), "1.0.0", ModeAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Updated to version 1.1.0, restart ytrss to use it"; msg != want {
+		t.Errorf("Check = %q, want %q", msg, want)
+	}
+	if got := readFile(t, exe); got != "v1.1.0" {
+		t.Errorf("executable = %q, want the release", got)
+	}
+	if got := readFile(t, backupPath(exe)); got != "old" {
+		t.Errorf("backup = %q, want the replaced executable", got)
+	}
+	if state := LoadState(); state.PreviousVersion != "1.0.0" {
+		t.Errorf("PreviousVersion = %q, want 1.0.0", state.PreviousVersion)
+	}
+}
+
+func TestCheckUpToDate(t *testing.T) {
+	exe := fakeExecutable(t)
+	srv := releaseServer(t, newRelease(t, "1.1.0", "v1.1.0"))
+	u := newTestUpdater(t, srv, Options{})
+
+	msg, err := u.Check(context.Background(), "1.1.0", ModeAuto)
+	if err != nil || msg != "" {
+		t.Errorf("Check = %q, %v, want nothing to report", msg, err)
+	}
+	if got := readFile(t, exe); got != "old" {
+		t.Errorf("executable = %q, want it left alone", got)
+	}
+}
+
+func TestFindRelease(t *testing.T) {
+	fakeExecutable(t)
+	srv := releaseServer(t, newRelease(t, "1.0.0", "v1.0.0"), newRelease(t, "1.1.0", "v1.1.0"))
+	u := newTestUpdater(t, srv, Options{})
+
+	for _, version := range []string{"1.0.0", "v1.0.0"} {
+		release, err := u.FindRelease(context.Background(), version)
+		if err != nil {
+			t.Fatalf("FindRelease(%q): %v", version, err)
+		}
+		if release.Version() != "1.0.0" || release.AssetName != GetAssetName("1.0.0") {
+			t.Errorf("FindRelease(%q) = %s with %s", version, release.Version(), release.AssetName)
+		}
+	}
+
+	if _, err := u.FindRelease(context.Background(), "2.0.0"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("FindRelease(2.0.0) error = %v, want not found", err)
+	}
+
+	u = newTestUpdater(t, srv, Options{Pin: "~1.1"})
+	if _, err := u.FindRelease(context.Background(), "1.0.0"); err == nil || !strings.Contains(err.Error(), "outside the pin") {
+		t.Errorf("pinned FindRelease(1.0.0) error = %v, want it refused", err)
+	}
+}
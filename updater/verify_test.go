@@ -0,0 +1,133 @@
+package updater
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+
+	selfupdate "github.com/creativeprojects/go-selfupdate"
+)
+
+func generateKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// publicKeyPEM encodes key the way Options.PublicKey expects it.
+func publicKeyPEM(t *testing.T, key *ecdsa.PrivateKey) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+// sign adds checksums.txt.sig to rel, signed with key.
+func sign(t *testing.T, rel fakeRelease, key *ecdsa.PrivateKey) {
+	t.Helper()
+	digest := sha256.Sum256(rel.Files[checksumsFile])
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel.Files[checksumsFile+".sig"] = sig
+}
+
+// checkRefused runs an automatic update of rel and expects it to fail
+// with target, leaving the executable alone.
+func checkRefused(t *testing.T, rel fakeRelease, opts Options, target error, message string) {
+	t.Helper()
+	exe := fakeExecutable(t)
+	u := newTestUpdater(t, releaseServer(t, rel), opts)
+
+	msg, err := u.Check(context.Background(), "1.0.0", ModeAuto)
+	if !errors.Is(err, target) {
+		t.Fatalf("Check = %q, %v, want %v", msg, err, target)
+	}
+	if !strings.Contains(err.Error(), message) {
+		t.Errorf("error %q does not explain %q", err, message)
+	}
+	if got := readFile(t, exe); got != "old" {
+		t.Errorf("executable = %q, want it left alone", got)
+	}
+}
+
+func TestUpdateChecksumMismatch(t *testing.T) {
+	rel := newRelease(t, "1.1.0", "v1.1.0")
+	rel.Files[checksumsFile] = []byte(strings.Repeat("0", 64) + "  " + GetAssetName("1.1.0") + "\n")
+
+	checkRefused(t, rel, Options{}, selfupdate.ErrChecksumValidationFailed, "the download does not match checksums.txt")
+}
+
+func TestUpdateChecksumMissingAsset(t *testing.T) {
+	rel := newRelease(t, "1.1.0", "v1.1.0")
+	rel.Files[checksumsFile] = []byte(strings.Repeat("0", 64) + "  ytrss-cli_1.1.0_plan9_mips.tar.gz\n")
+
+	checkRefused(t, rel, Options{}, selfupdate.ErrHashNotFound, "the download does not match checksums.txt")
+}
+
+func TestUpdateWithoutChecksums(t *testing.T) {
+	rel := newRelease(t, "1.1.0", "v1.1.0")
+	delete(rel.Files, checksumsFile)
+
+	checkRefused(t, rel, Options{}, selfupdate.ErrValidationAssetNotFound, "the release cannot be verified")
+}
+
+func TestUpdateSigned(t *testing.T) {
+	key := generateKey(t)
+	rel := newRelease(t, "1.1.0", "v1.1.0")
+	sign(t, rel, key)
+
+	exe := fakeExecutable(t)
+	u := newTestUpdater(t, releaseServer(t, rel), Options{PublicKey: publicKeyPEM(t, key)})
+	if _, err := u.Check(context.Background(), "1.0.0", ModeAuto); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, exe); got != "v1.1.0" {
+		t.Errorf("executable = %q, want the release", got)
+	}
+}
+
+func TestUpdateSignedWithOtherKey(t *testing.T) {
+	rel := newRelease(t, "1.1.0", "v1.1.0")
+	sign(t, rel, generateKey(t))
+
+	opts := Options{PublicKey: publicKeyPEM(t, generateKey(t))}
+	checkRefused(t, rel, opts, selfupdate.ErrECDSAValidationFailed, "checksums.txt is not signed with the pinned key")
+}
+
+func TestUpdateSignatureOfOtherChecksums(t *testing.T) {
+	key := generateKey(t)
+	rel := newRelease(t, "1.1.0", "v1.1.0")
+	sign(t, rel, key)
+	rel.Files[checksumsFile] = append(rel.Files[checksumsFile], "# tampered\n"...)
+
+	checkRefused(t, rel, Options{PublicKey: publicKeyPEM(t, key)}, selfupdate.ErrECDSAValidationFailed, "checksums.txt is not signed with the pinned key")
+}
+
+func TestUpdateMalformedSignature(t *testing.T) {
+	key := generateKey(t)
+	rel := newRelease(t, "1.1.0", "v1.1.0")
+	rel.Files[checksumsFile+".sig"] = []byte("not a signature")
+
+	checkRefused(t, rel, Options{PublicKey: publicKeyPEM(t, key)}, selfupdate.ErrInvalidECDSASignature, "checksums.txt is not signed with the pinned key")
+}
+
+func TestUpdateUnsigned(t *testing.T) {
+	key := generateKey(t)
+	rel := newRelease(t, "1.1.0", "v1.1.0")
+
+	checkRefused(t, rel, Options{PublicKey: publicKeyPEM(t, key)}, selfupdate.ErrValidationAssetNotFound, "the release cannot be verified")
+}
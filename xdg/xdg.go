@@ -0,0 +1,30 @@
+package xdg
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const appName = "ytrss"
+
+// StateDir returns $XDG_STATE_HOME/ytrss, defaulting to ~/.local/state/ytrss.
+func StateDir() (string, error) {
+	return dir("XDG_STATE_HOME", ".local", "state")
+}
+
+// ConfigDir returns $XDG_CONFIG_HOME/ytrss, defaulting to ~/.config/ytrss.
+func ConfigDir() (string, error) {
+	return dir("XDG_CONFIG_HOME", ".config")
+}
+
+func dir(env string, fallback ...string) (string, error) {
+	if base := os.Getenv(env); base != "" && filepath.IsAbs(base) {
+		return filepath.Join(base, appName), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(append(append([]string{home}, fallback...), appName)...), nil
+}
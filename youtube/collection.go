@@ -0,0 +1,79 @@
+package youtube
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+var ErrNotCollection = errors.New("not a playlist or channel URL")
+
+type CollectionKind string
+
+const (
+	KindPlaylist CollectionKind = "playlist"
+	KindChannel  CollectionKind = "channel"
+	KindUser     CollectionKind = "user"
+)
+
+// Collection is a playlist or channel whose videos can be listed through
+// the public YouTube Atom feed.
+type Collection struct {
+	Kind CollectionKind
+	ID   string
+}
+
+func (c Collection) String() string {
+	return string(c.Kind) + " " + c.ID
+}
+
+// FeedURL returns the Atom feed listing the most recent videos of the
+// collection.
+func (c Collection) FeedURL() string {
+	param := "playlist_id"
+	switch c.Kind {
+	case KindChannel:
+		param = "channel_id"
+	case KindUser:
+		param = "user"
+	}
+	return "https://www.youtube.com/feeds/videos.xml?" + param + "=" + url.QueryEscape(c.ID)
+}
+
+// ParseCollection recognizes playlist URLs (/playlist?list=) and channel
+// URLs (/channel/<id> and /user/<name>). Watch URLs that carry a list
+// parameter are treated as videos and return ErrNotCollection. Handle and
+// custom URLs (/@name, /c/name) are reported as invalid because the feed
+// cannot be resolved from them.
+func ParseCollection(raw string) (Collection, error) {
+	u, err := parse(raw)
+	if err != nil {
+		return Collection{}, err
+	}
+	if !youtubeHosts[u.Host] {
+		return Collection{}, ErrNotCollection
+	}
+
+	path := strings.TrimSuffix(u.Path, "/")
+	switch {
+	case path == "/playlist":
+		if list := u.Query().Get("list"); list != "" {
+			return Collection{Kind: KindPlaylist, ID: list}, nil
+		}
+		return Collection{}, invalid("playlist URL without a list parameter")
+	case strings.HasPrefix(path, "/channel/"):
+		id, _, _ := strings.Cut(strings.TrimPrefix(path, "/channel/"), "/")
+		if id != "" {
+			return Collection{Kind: KindChannel, ID: id}, nil
+		}
+	case strings.HasPrefix(path, "/user/"):
+		name, _, _ := strings.Cut(strings.TrimPrefix(path, "/user/"), "/")
+		if name != "" {
+			return Collection{Kind: KindUser, ID: name}, nil
+		}
+	case strings.HasPrefix(path, "/@"), strings.HasPrefix(path, "/c/"):
+		return Collection{}, invalid("%s cannot be expanded, use the channel's /channel/<id> URL instead", raw)
+	}
+
+	return Collection{}, ErrNotCollection
+}
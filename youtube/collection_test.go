@@ -0,0 +1,60 @@
+package youtube
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseCollection(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    Collection
+		wantErr error
+	}{
+		{url: "https://www.youtube.com/playlist?list=PLabc123", want: Collection{Kind: KindPlaylist, ID: "PLabc123"}},
+		{url: "youtube.com/playlist?list=PLabc123&si=track", want: Collection{Kind: KindPlaylist, ID: "PLabc123"}},
+		{url: "https://m.youtube.com/playlist/?list=PLabc123", want: Collection{Kind: KindPlaylist, ID: "PLabc123"}},
+		{url: "https://music.youtube.com/playlist?list=OLAK5uy_abc", want: Collection{Kind: KindPlaylist, ID: "OLAK5uy_abc"}},
+		{url: "https://www.youtube.com/channel/UCabcdefghijklmnopqrstuv", want: Collection{Kind: KindChannel, ID: "UCabcdefghijklmnopqrstuv"}},
+		{url: "https://www.youtube.com/channel/UCabcdefghijklmnopqrstuv/videos", want: Collection{Kind: KindChannel, ID: "UCabcdefghijklmnopqrstuv"}},
+		{url: "https://www.youtube.com/user/example", want: Collection{Kind: KindUser, ID: "example"}},
+
+		{url: "https://www.youtube.com/playlist", wantErr: ErrInvalidURL},
+		{url: "https://www.youtube.com/@example", wantErr: ErrInvalidURL},
+		{url: "https://www.youtube.com/c/example", wantErr: ErrInvalidURL},
+		{url: "ftp://www.youtube.com/playlist?list=PLabc123", wantErr: ErrInvalidURL},
+
+		// Videos, even with playlist context, are not collections.
+		{url: "https://www.youtube.com/watch?v=dQw4w9WgXcQ&list=PLabc123", wantErr: ErrNotCollection},
+		{url: "https://youtu.be/dQw4w9WgXcQ", wantErr: ErrNotCollection},
+		{url: "https://vimeo.com/channel/123", wantErr: ErrNotCollection},
+	}
+	for _, tt := range tests {
+		got, err := ParseCollection(tt.url)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseCollection(%q) error = %v, want %v", tt.url, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseCollection(%q) = %v, %v, want %v", tt.url, got, err, tt.want)
+		}
+	}
+}
+
+func TestCollectionFeedURL(t *testing.T) {
+	tests := []struct {
+		c    Collection
+		want string
+	}{
+		{Collection{Kind: KindPlaylist, ID: "PLabc123"}, "https://www.youtube.com/feeds/videos.xml?playlist_id=PLabc123"},
+		{Collection{Kind: KindChannel, ID: "UCabc"}, "https://www.youtube.com/feeds/videos.xml?channel_id=UCabc"},
+		{Collection{Kind: KindUser, ID: "a b"}, "https://www.youtube.com/feeds/videos.xml?user=a+b"},
+	}
+	for _, tt := range tests {
+		if got := tt.c.FeedURL(); got != tt.want {
+			t.Errorf("%v.FeedURL() = %q, want %q", tt.c, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package youtube
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Fetcher retrieves the raw Atom feed of a collection.
+type Fetcher interface {
+	Fetch(ctx context.Context, feedURL string) (io.ReadCloser, error)
+}
+
+type HTTPFetcher struct {
+	Client *http.Client
+}
+
+func (f HTTPFetcher) Fetch(ctx context.Context, feedURL string) (io.ReadCloser, error) {
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetching %s: %s", feedURL, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+type FeedVideo struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	Published time.Time `json:"published"`
+}
+
+type atomFeed struct {
+	Title   string      `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	VideoID   string `xml:"http://www.youtube.com/xml/schemas/2015 videoId"`
+	Title     string `xml:"title"`
+	Published string `xml:"published"`
+}
+
+// ParseFeed decodes a YouTube Atom feed into its title and videos, in feed
+// order.
+func ParseFeed(r io.Reader) (string, []FeedVideo, error) {
+	var feed atomFeed
+	if err := xml.NewDecoder(r).Decode(&feed); err != nil {
+		return "", nil, fmt.Errorf("decoding feed: %w", err)
+	}
+
+	videos := make([]FeedVideo, 0, len(feed.Entries))
+	for _, e := range feed.Entries {
+		if !videoIDPattern.MatchString(e.VideoID) {
+			continue
+		}
+		published, _ := time.Parse(time.RFC3339, e.Published)
+		videos = append(videos, FeedVideo{
+			ID:        e.VideoID,
+			Title:     e.Title,
+			URL:       WatchURL(e.VideoID),
+			Published: published,
+		})
+	}
+	return feed.Title, videos, nil
+}
+
+// Expand lists the videos of a collection. YouTube feeds only include the
+// most recent uploads, so long playlists and channels are truncated.
+func Expand(ctx context.Context, f Fetcher, c Collection) (string, []FeedVideo, error) {
+	body, err := f.Fetch(ctx, c.FeedURL())
+	if err != nil {
+		return "", nil, err
+	}
+	defer body.Close()
+
+	return ParseFeed(body)
+}
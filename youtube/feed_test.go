@@ -0,0 +1,111 @@
+package youtube
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fixtureFetcher serves feeds from testdata, keyed by feed URL.
+type fixtureFetcher map[string]string
+
+func (f fixtureFetcher) Fetch(ctx context.Context, feedURL string) (io.ReadCloser, error) {
+	name, ok := f[feedURL]
+	if !ok {
+		return nil, errors.New("unexpected feed " + feedURL)
+	}
+	return os.Open(filepath.Join("testdata", name))
+}
+
+func openFixture(t *testing.T, name string) io.Reader {
+	t.Helper()
+	f, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+func TestParseFeed(t *testing.T) {
+	title, videos, err := ParseFeed(openFixture(t, "playlist.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title != "Conference Talks" {
+		t.Errorf("title = %q, want %q", title, "Conference Talks")
+	}
+
+	want := []FeedVideo{
+		{
+			ID:        "dQw4w9WgXcQ",
+			Title:     "Opening Keynote",
+			URL:       "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+			Published: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC),
+		},
+		// The entry with a malformed video ID is skipped and an
+		// unparseable date leaves Published zero.
+		{
+			ID:    "9bZkp7q19f0",
+			Title: "Closing Panel & Q&A",
+			URL:   "https://www.youtube.com/watch?v=9bZkp7q19f0",
+		},
+	}
+	if len(videos) != len(want) {
+		t.Fatalf("got %d videos, want %d: %+v", len(videos), len(want), videos)
+	}
+	for i, v := range videos {
+		if v.ID != want[i].ID || v.Title != want[i].Title || v.URL != want[i].URL || !v.Published.Equal(want[i].Published) {
+			t.Errorf("video %d = %+v, want %+v", i, v, want[i])
+		}
+	}
+}
+
+func TestParseFeedEmpty(t *testing.T) {
+	title, videos, err := ParseFeed(openFixture(t, "empty.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title != "Empty Playlist" || len(videos) != 0 {
+		t.Errorf("got %q with %d videos, want %q with none", title, len(videos), "Empty Playlist")
+	}
+}
+
+func TestParseFeedMalformed(t *testing.T) {
+	if _, _, err := ParseFeed(openFixture(t, "truncated.xml")); err == nil {
+		t.Error("expected an error for a truncated feed")
+	}
+}
+
+func TestExpand(t *testing.T) {
+	fetcher := fixtureFetcher{
+		"https://www.youtube.com/feeds/videos.xml?playlist_id=PLabc123":                "playlist.xml",
+		"https://www.youtube.com/feeds/videos.xml?channel_id=UCabcdefghijklmnopqrstuv": "channel.xml",
+	}
+
+	tests := []struct {
+		url    string
+		title  string
+		videos int
+	}{
+		{"https://www.youtube.com/playlist?list=PLabc123", "Conference Talks", 2},
+		{"https://www.youtube.com/channel/UCabcdefghijklmnopqrstuv", "Example Channel", 1},
+	}
+	for _, tt := range tests {
+		c, err := ParseCollection(tt.url)
+		if err != nil {
+			t.Fatalf("ParseCollection(%q): %v", tt.url, err)
+		}
+		title, videos, err := Expand(context.Background(), fetcher, c)
+		if err != nil {
+			t.Fatalf("Expand(%v): %v", c, err)
+		}
+		if title != tt.title || len(videos) != tt.videos {
+			t.Errorf("Expand(%v) = %q with %d videos, want %q with %d", c, title, len(videos), tt.title, tt.videos)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package youtube
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var ErrInvalidURL = errors.New("invalid YouTube URL")
+
+var videoIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`)
+
+var youtubeHosts = map[string]bool{
+	"youtube.com":              true,
+	"www.youtube.com":          true,
+	"m.youtube.com":            true,
+	"music.youtube.com":        true,
+	"youtube-nocookie.com":     true,
+	"www.youtube-nocookie.com": true,
+}
+
+// pathPrefixes are the URL paths that carry the video ID as their next
+// segment, e.g. /shorts/<id>.
+var pathPrefixes = []string{"/shorts/", "/live/", "/embed/", "/v/", "/e/"}
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidURL, fmt.Sprintf(format, args...))
+}
+
+// parse accepts URLs with or without a scheme and returns them with a
+// lower-cased host.
+func parse(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, invalid("empty URL")
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, invalid("%q is not a URL", raw)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, invalid("unsupported scheme %q", u.Scheme)
+	}
+	u.Host = strings.ToLower(u.Hostname())
+	return u, nil
+}
+
+// VideoID extracts the video ID from a watch, youtu.be, shorts, live, embed,
+// music.youtube.com or mobile URL.
+func VideoID(raw string) (string, error) {
+	u, err := parse(raw)
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	switch {
+	case u.Host == "youtu.be":
+		id = strings.Trim(u.Path, "/")
+	case youtubeHosts[u.Host]:
+		if u.Path == "/watch" || u.Path == "/watch/" {
+			id = u.Query().Get("v")
+			break
+		}
+		for _, prefix := range pathPrefixes {
+			if rest, ok := strings.CutPrefix(u.Path, prefix); ok {
+				id, _, _ = strings.Cut(rest, "/")
+				break
+			}
+		}
+		if id == "" {
+			_, err := ParseCollection(raw)
+			switch {
+			case err == nil:
+				return "", invalid("%s is a playlist or channel, not a video", raw)
+			case errors.Is(err, ErrInvalidURL):
+				return "", err
+			}
+			return "", invalid("%s is not a video URL", raw)
+		}
+	default:
+		return "", invalid("%s is not a YouTube host", u.Host)
+	}
+
+	if id == "" {
+		return "", invalid("missing video ID")
+	}
+	if !videoIDPattern.MatchString(id) {
+		return "", invalid("malformed video ID %q", id)
+	}
+	return id, nil
+}
+
+// WatchURL returns the canonical watch URL of a video.
+func WatchURL(videoID string) string {
+	return "https://www.youtube.com/watch?v=" + videoID
+}
+
+// Normalize validates raw and returns its canonical watch URL, dropping
+// tracking parameters, timestamps and playlist context.
+func Normalize(raw string) (string, error) {
+	id, err := VideoID(raw)
+	if err != nil {
+		return "", err
+	}
+	return WatchURL(id), nil
+}
@@ -0,0 +1,79 @@
+package youtube
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVideoID(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"watch", "https://www.youtube.com/watch?v=dQw4w9WgXcQ", "dQw4w9WgXcQ"},
+		{"watch without scheme", "youtube.com/watch?v=dQw4w9WgXcQ", "dQw4w9WgXcQ"},
+		{"watch over http", "http://www.youtube.com/watch?v=dQw4w9WgXcQ", "dQw4w9WgXcQ"},
+		{"watch with tracking and timestamp", "https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=42s&si=abc&feature=share", "dQw4w9WgXcQ"},
+		{"watch in a playlist", "https://www.youtube.com/watch?v=dQw4w9WgXcQ&list=PLabc123&index=2", "dQw4w9WgXcQ"},
+		{"watch with trailing slash", "https://www.youtube.com/watch/?v=dQw4w9WgXcQ", "dQw4w9WgXcQ"},
+		{"upper-case host", "https://WWW.YouTube.com/watch?v=dQw4w9WgXcQ", "dQw4w9WgXcQ"},
+		{"surrounding whitespace", "  https://www.youtube.com/watch?v=dQw4w9WgXcQ \n", "dQw4w9WgXcQ"},
+		{"youtu.be", "https://youtu.be/dQw4w9WgXcQ", "dQw4w9WgXcQ"},
+		{"youtu.be with timestamp", "youtu.be/dQw4w9WgXcQ?t=10&si=abc", "dQw4w9WgXcQ"},
+		{"shorts", "https://www.youtube.com/shorts/dQw4w9WgXcQ", "dQw4w9WgXcQ"},
+		{"shorts with feature", "https://youtube.com/shorts/dQw4w9WgXcQ?feature=share", "dQw4w9WgXcQ"},
+		{"live", "https://www.youtube.com/live/dQw4w9WgXcQ?si=abc", "dQw4w9WgXcQ"},
+		{"embed", "https://www.youtube.com/embed/dQw4w9WgXcQ?start=30", "dQw4w9WgXcQ"},
+		{"embed without cookies", "https://www.youtube-nocookie.com/embed/dQw4w9WgXcQ", "dQw4w9WgXcQ"},
+		{"old embed", "https://www.youtube.com/v/dQw4w9WgXcQ", "dQw4w9WgXcQ"},
+		{"music", "https://music.youtube.com/watch?v=dQw4w9WgXcQ&feature=share", "dQw4w9WgXcQ"},
+		{"mobile", "https://m.youtube.com/watch?v=dQw4w9WgXcQ", "dQw4w9WgXcQ"},
+		{"mobile shorts", "https://m.youtube.com/shorts/dQw4w9WgXcQ", "dQw4w9WgXcQ"},
+		{"ID with dash and underscore", "https://youtu.be/a_b-c_d-e_f", "a_b-c_d-e_f"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := VideoID(tt.url)
+			if err != nil || got != tt.want {
+				t.Errorf("VideoID(%q) = %q, %v, want %q", tt.url, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVideoIDInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"empty", ""},
+		{"not a URL", "://"},
+		{"other host", "https://vimeo.com/watch?v=dQw4w9WgXcQ"},
+		{"look-alike host", "https://youtube.com.evil.example/watch?v=dQw4w9WgXcQ"},
+		{"unsupported scheme", "ftp://www.youtube.com/watch?v=dQw4w9WgXcQ"},
+		{"watch without v", "https://www.youtube.com/watch?list=PLabc123"},
+		{"short ID", "https://youtu.be/dQw4w9WgXc"},
+		{"long ID", "https://www.youtube.com/watch?v=dQw4w9WgXcQQ"},
+		{"bad characters", "https://www.youtube.com/shorts/dQw4w9W$XcQ"},
+		{"empty youtu.be path", "https://youtu.be/"},
+		{"playlist", "https://www.youtube.com/playlist?list=PLabc123"},
+		{"channel", "https://www.youtube.com/channel/UCabcdefghijklmnopqrstuv"},
+		{"handle", "https://www.youtube.com/@example"},
+		{"home page", "https://www.youtube.com/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if id, err := VideoID(tt.url); !errors.Is(err, ErrInvalidURL) {
+				t.Errorf("VideoID(%q) = %q, %v, want ErrInvalidURL", tt.url, id, err)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	got, err := Normalize("https://youtu.be/dQw4w9WgXcQ?t=42&si=abc")
+	if want := "https://www.youtube.com/watch?v=dQw4w9WgXcQ"; err != nil || got != want {
+		t.Errorf("Normalize = %q, %v, want %q", got, err, want)
+	}
+}